@@ -0,0 +1,219 @@
+// Package assets maps logical asset paths to content-hashed file names,
+// so that assets can be served with immutable caching and templates
+// can refer to them with a stable name, e.g. AssetPath("app.css").
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/fragmenta/mux"
+)
+
+// Usage
+// err := assets.Setup("public/assets", "/assets")
+// m.Get("/assets/{path:.+}", assets.Default.ServeAsset)
+// ...
+// <link href="{{ assetPath "app.css" }}" rel="stylesheet">
+
+// Default is the collection used by the package level AssetPath helper.
+var Default = New("public/assets", "/assets")
+
+// Setup sets the Default collection to serve files from root at the url prefix,
+// and compiles fingerprints for all files in root.
+func Setup(root, prefix string) error {
+	Default = New(root, prefix)
+	return Default.Compile()
+}
+
+// AssetPath returns the fingerprinted url for the logical asset name
+// using the Default collection. It is suitable for use as a template helper.
+func AssetPath(name string) string {
+	return Default.Path(name)
+}
+
+// Collection maps logical asset names (app.css) to fingerprinted names (app-1a2b3c4d5e6f7a8b.css)
+// and serves assets requested by either name.
+type Collection struct {
+	// Prefix is the url prefix under which assets are served
+	Prefix string
+
+	// Dev serves logical names from Path and disables caching,
+	// so that changes to assets are seen without recompiling.
+	Dev bool
+
+	// fsys is the filesystem assets are read from
+	fsys fs.FS
+
+	// server serves files from fsys once names are resolved
+	server *mux.FileServer
+
+	mu     sync.RWMutex
+	hashed map[string]string // logical name -> fingerprinted name
+	names  map[string]string // fingerprinted name -> logical name
+}
+
+// New returns a new collection serving files in the root directory at the url prefix.
+func New(root, prefix string) *Collection {
+	return NewFS(os.DirFS(root), prefix)
+}
+
+// NewFS returns a new collection serving files from fsys at the url prefix.
+func NewFS(fsys fs.FS, prefix string) *Collection {
+	return &Collection{
+		Prefix: strings.TrimSuffix(prefix, "/"),
+		fsys:   fsys,
+		server: mux.NewFileServerFS(fsys),
+		hashed: make(map[string]string),
+		names:  make(map[string]string),
+	}
+}
+
+// Compile walks the collection files and fingerprints each file using a hash of its contents.
+func (c *Collection) Compile() error {
+	hashed := make(map[string]string)
+
+	err := fs.WalkDir(c.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		hash, err := c.hashFile(p)
+		if err != nil {
+			return err
+		}
+		hashed[p] = fingerprint(p, hash)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.setNames(hashed)
+	return nil
+}
+
+// LoadManifest loads a json manifest mapping logical names to fingerprinted names,
+// this is typically generated at build time with SaveManifest to avoid hashing on startup.
+func (c *Collection) LoadManifest(p string) error {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+
+	hashed := make(map[string]string)
+	err = json.Unmarshal(data, &hashed)
+	if err != nil {
+		return err
+	}
+
+	c.setNames(hashed)
+	return nil
+}
+
+// SaveManifest writes the json manifest of logical names to fingerprinted names to path.
+func (c *Collection) SaveManifest(p string) error {
+	c.mu.RLock()
+	data, err := json.MarshalIndent(c.hashed, "", "  ")
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0644)
+}
+
+// Path returns the fingerprinted url for the logical asset name,
+// if the name is unknown the unhashed url is returned.
+func (c *Collection) Path(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if c.Dev {
+		return c.Prefix + "/" + name
+	}
+
+	c.mu.RLock()
+	hashed, ok := c.hashed[name]
+	c.mu.RUnlock()
+	if ok {
+		name = hashed
+	}
+
+	return c.Prefix + "/" + name
+}
+
+// ServeAsset serves the asset at the request path, it conforms to mux.HandlerFunc.
+// Fingerprinted names are served with immutable caching, logical names must be revalidated.
+func (c *Collection) ServeAsset(w http.ResponseWriter, r *http.Request) error {
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), c.Prefix+"/")
+
+	c.mu.RLock()
+	logical, ok := c.names[name]
+	c.mu.RUnlock()
+
+	if ok {
+		w.Header().Set("Cache-Control", mux.CacheImmutable)
+	} else {
+		logical = name
+		w.Header().Set("Cache-Control", mux.CacheNone)
+	}
+
+	// Serve the logical file from our filesystem
+	fr := new(http.Request)
+	*fr = *r
+	u := *r.URL
+	u.Path = "/" + logical
+	fr.URL = &u
+
+	if c.Dev {
+		dev := *c.server
+		dev.Dev = true
+		return dev.ServeFile(w, fr)
+	}
+
+	return c.server.ServeFile(w, fr)
+}
+
+// setNames replaces the mapping of logical to fingerprinted names.
+func (c *Collection) setNames(hashed map[string]string) {
+	names := make(map[string]string, len(hashed))
+	for k, v := range hashed {
+		names[v] = k
+	}
+
+	c.mu.Lock()
+	c.hashed = hashed
+	c.names = names
+	c.mu.Unlock()
+}
+
+// hashFile returns a hex encoded hash of the file contents.
+func (c *Collection) hashFile(p string) (string, error) {
+	f, err := c.fsys.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:8]), nil
+}
+
+// fingerprint inserts the hash into the file name before the extension.
+func fingerprint(p, hash string) string {
+	ext := path.Ext(p)
+	return strings.TrimSuffix(p, ext) + "-" + hash + ext
+}
@@ -0,0 +1,122 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fragmenta/mux"
+)
+
+// testFS holds the assets used in tests
+var testFS = fstest.MapFS{
+	"app.css":        {Data: []byte("body { color: red; }")},
+	"js/app.js":      {Data: []byte("console.log(1);")},
+	".hidden":        {Data: []byte("secret")},
+	"images/logo.sv": {Data: []byte("<svg></svg>")},
+}
+
+// TestPath tests logical names are mapped to fingerprinted names.
+func TestPath(t *testing.T) {
+	c := NewFS(testFS, "/assets/")
+	if got := c.Path("app.css"); got != "/assets/app.css" {
+		t.Errorf("assets: wrong path before compile got:%s", got)
+	}
+	if err := c.Compile(); err != nil {
+		t.Fatalf("assets: error compiling %s", err)
+	}
+
+	css := c.Path("app.css")
+	if css != "/assets/app-"+hash(t, c, "app.css")+".css" {
+		t.Errorf("assets: wrong path got:%s", css)
+	}
+	if got := c.Path("/js/app.js"); got != "/assets/js/app-"+hash(t, c, "js/app.js")+".js" {
+		t.Errorf("assets: wrong nested path got:%s", got)
+	}
+	if got := c.Path(".hidden"); got != "/assets/.hidden" {
+		t.Errorf("assets: hidden file fingerprinted got:%s", got)
+	}
+	if got := c.Path("missing.css"); got != "/assets/missing.css" {
+		t.Errorf("assets: wrong path for unknown asset got:%s", got)
+	}
+
+	c.Dev = true
+	if got := c.Path("app.css"); got != "/assets/app.css" {
+		t.Errorf("assets: wrong dev path got:%s", got)
+	}
+}
+
+// TestServeAsset tests fingerprinted names are served with immutable caching.
+func TestServeAsset(t *testing.T) {
+	c := NewFS(testFS, "/assets")
+	if err := c.Compile(); err != nil {
+		t.Fatalf("assets: error compiling %s", err)
+	}
+
+	tests := []struct {
+		path  string
+		code  int
+		cache string
+		body  string
+	}{
+		{c.Path("app.css"), http.StatusOK, mux.CacheImmutable, "body { color: red; }"},
+		{"/assets/app.css", http.StatusOK, mux.CacheNone, "body { color: red; }"},
+		{c.Path("js/app.js"), http.StatusOK, mux.CacheImmutable, "console.log(1);"},
+		{"/assets/app-0000000000000000.css", http.StatusNotFound, mux.CacheNone, ""},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		err := c.ServeAsset(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		code := w.Code
+		if err != nil {
+			code = mux.ErrorStatus(err)
+		}
+		if code != tc.code {
+			t.Errorf("assets: wrong status for %s got:%d want:%d", tc.path, code, tc.code)
+			continue
+		}
+		if w.Header().Get("Cache-Control") != tc.cache {
+			t.Errorf("assets: wrong cache control for %s got:%s want:%s", tc.path, w.Header().Get("Cache-Control"), tc.cache)
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("assets: wrong body for %s got:%q want:%q", tc.path, w.Body.String(), tc.body)
+		}
+	}
+}
+
+// TestManifest tests the manifest is saved and loaded.
+func TestManifest(t *testing.T) {
+	c := NewFS(testFS, "/assets")
+	if err := c.Compile(); err != nil {
+		t.Fatalf("assets: error compiling %s", err)
+	}
+	p := filepath.Join(t.TempDir(), "manifest.json")
+	if err := c.SaveManifest(p); err != nil {
+		t.Fatalf("assets: error saving manifest %s", err)
+	}
+
+	loaded := NewFS(testFS, "/assets")
+	if err := loaded.LoadManifest(p); err != nil {
+		t.Fatalf("assets: error loading manifest %s", err)
+	}
+	for _, name := range []string{"app.css", "js/app.js", "images/logo.sv"} {
+		if loaded.Path(name) != c.Path(name) {
+			t.Errorf("assets: wrong path from manifest got:%s want:%s", loaded.Path(name), c.Path(name))
+		}
+	}
+}
+
+// hash returns the fingerprint hash for the file p in c
+func hash(t *testing.T, c *Collection, p string) string {
+	h, err := c.hashFile(p)
+	if err != nil {
+		t.Fatalf("assets: error hashing %s %s", p, err)
+	}
+	if len(h) != 16 {
+		t.Errorf("assets: wrong hash length for %s got:%d", p, len(h))
+	}
+	return h
+}
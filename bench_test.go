@@ -0,0 +1,453 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Route sets and benchmarks based on those used by go-http-routing-benchmark.
+// Run with:
+// go test -run xxx -bench . -benchmem
+//
+// Allocation targets (checked by TestAllocationBudget):
+// Match      0 allocs (cached and uncached)
+// ParseInto  0 allocs with a reused buffer
+// Params     allocates only for the params returned (see maxParamsAllocs)
+
+// benchRoute is a route in httprouter syntax, with :name params and *name catch-alls
+type benchRoute struct {
+	method string
+	path   string
+}
+
+// githubAPI is the GitHub API route set
+var githubAPI = []benchRoute{
+	// OAuth Authorizations
+	{"GET", "/authorizations"},
+	{"GET", "/authorizations/:id"},
+	{"POST", "/authorizations"},
+	{"DELETE", "/authorizations/:id"},
+	{"GET", "/applications/:client_id/tokens/:access_token"},
+	{"DELETE", "/applications/:client_id/tokens"},
+	{"DELETE", "/applications/:client_id/tokens/:access_token"},
+
+	// Activity
+	{"GET", "/events"},
+	{"GET", "/repos/:owner/:repo/events"},
+	{"GET", "/networks/:owner/:repo/events"},
+	{"GET", "/orgs/:org/events"},
+	{"GET", "/users/:user/received_events"},
+	{"GET", "/users/:user/received_events/public"},
+	{"GET", "/users/:user/events"},
+	{"GET", "/users/:user/events/public"},
+	{"GET", "/users/:user/events/orgs/:org"},
+	{"GET", "/feeds"},
+	{"GET", "/notifications"},
+	{"GET", "/repos/:owner/:repo/notifications"},
+	{"PUT", "/notifications"},
+	{"PUT", "/repos/:owner/:repo/notifications"},
+	{"GET", "/notifications/threads/:id"},
+	{"GET", "/notifications/threads/:id/subscription"},
+	{"PUT", "/notifications/threads/:id/subscription"},
+	{"DELETE", "/notifications/threads/:id/subscription"},
+	{"GET", "/repos/:owner/:repo/stargazers"},
+	{"GET", "/users/:user/starred"},
+	{"GET", "/user/starred"},
+	{"GET", "/user/starred/:owner/:repo"},
+	{"PUT", "/user/starred/:owner/:repo"},
+	{"DELETE", "/user/starred/:owner/:repo"},
+	{"GET", "/repos/:owner/:repo/subscribers"},
+	{"GET", "/users/:user/subscriptions"},
+	{"GET", "/user/subscriptions"},
+	{"GET", "/repos/:owner/:repo/subscription"},
+	{"PUT", "/repos/:owner/:repo/subscription"},
+	{"DELETE", "/repos/:owner/:repo/subscription"},
+	{"GET", "/user/subscriptions/:owner/:repo"},
+	{"PUT", "/user/subscriptions/:owner/:repo"},
+	{"DELETE", "/user/subscriptions/:owner/:repo"},
+
+	// Gists
+	{"GET", "/users/:user/gists"},
+	{"GET", "/gists"},
+	{"GET", "/gists/:id"},
+	{"POST", "/gists"},
+	{"PUT", "/gists/:id/star"},
+	{"DELETE", "/gists/:id/star"},
+	{"GET", "/gists/:id/star"},
+	{"POST", "/gists/:id/forks"},
+	{"DELETE", "/gists/:id"},
+
+	// Git Data
+	{"GET", "/repos/:owner/:repo/git/blobs/:sha"},
+	{"POST", "/repos/:owner/:repo/git/blobs"},
+	{"GET", "/repos/:owner/:repo/git/commits/:sha"},
+	{"POST", "/repos/:owner/:repo/git/commits"},
+	{"GET", "/repos/:owner/:repo/git/refs/*ref"},
+	{"GET", "/repos/:owner/:repo/git/refs"},
+	{"POST", "/repos/:owner/:repo/git/refs"},
+	{"DELETE", "/repos/:owner/:repo/git/refs/*ref"},
+	{"GET", "/repos/:owner/:repo/git/tags/:sha"},
+	{"POST", "/repos/:owner/:repo/git/tags"},
+	{"GET", "/repos/:owner/:repo/git/trees/:sha"},
+	{"POST", "/repos/:owner/:repo/git/trees"},
+
+	// Issues
+	{"GET", "/issues"},
+	{"GET", "/user/issues"},
+	{"GET", "/orgs/:org/issues"},
+	{"GET", "/repos/:owner/:repo/issues"},
+	{"GET", "/repos/:owner/:repo/issues/:number"},
+	{"POST", "/repos/:owner/:repo/issues"},
+	{"GET", "/repos/:owner/:repo/assignees"},
+	{"GET", "/repos/:owner/:repo/assignees/:assignee"},
+	{"GET", "/repos/:owner/:repo/issues/:number/comments"},
+	{"POST", "/repos/:owner/:repo/issues/:number/comments"},
+	{"GET", "/repos/:owner/:repo/issues/:number/events"},
+	{"GET", "/repos/:owner/:repo/labels"},
+	{"GET", "/repos/:owner/:repo/labels/:name"},
+	{"POST", "/repos/:owner/:repo/labels"},
+	{"DELETE", "/repos/:owner/:repo/labels/:name"},
+	{"GET", "/repos/:owner/:repo/issues/:number/labels"},
+	{"POST", "/repos/:owner/:repo/issues/:number/labels"},
+	{"DELETE", "/repos/:owner/:repo/issues/:number/labels/:name"},
+	{"PUT", "/repos/:owner/:repo/issues/:number/labels"},
+	{"DELETE", "/repos/:owner/:repo/issues/:number/labels"},
+	{"GET", "/repos/:owner/:repo/milestones/:number/labels"},
+	{"GET", "/repos/:owner/:repo/milestones"},
+	{"GET", "/repos/:owner/:repo/milestones/:number"},
+	{"POST", "/repos/:owner/:repo/milestones"},
+	{"DELETE", "/repos/:owner/:repo/milestones/:number"},
+
+	// Miscellaneous
+	{"GET", "/emojis"},
+	{"GET", "/gitignore/templates"},
+	{"GET", "/gitignore/templates/:name"},
+	{"POST", "/markdown"},
+	{"POST", "/markdown/raw"},
+	{"GET", "/meta"},
+	{"GET", "/rate_limit"},
+
+	// Organizations
+	{"GET", "/users/:user/orgs"},
+	{"GET", "/user/orgs"},
+	{"GET", "/orgs/:org"},
+	{"GET", "/orgs/:org/members"},
+	{"GET", "/orgs/:org/members/:user"},
+	{"DELETE", "/orgs/:org/members/:user"},
+	{"GET", "/orgs/:org/public_members"},
+	{"GET", "/orgs/:org/public_members/:user"},
+	{"PUT", "/orgs/:org/public_members/:user"},
+	{"DELETE", "/orgs/:org/public_members/:user"},
+	{"GET", "/orgs/:org/teams"},
+	{"GET", "/teams/:id"},
+	{"POST", "/orgs/:org/teams"},
+	{"DELETE", "/teams/:id"},
+	{"GET", "/teams/:id/members"},
+	{"GET", "/teams/:id/members/:user"},
+	{"PUT", "/teams/:id/members/:user"},
+	{"DELETE", "/teams/:id/members/:user"},
+	{"GET", "/teams/:id/repos"},
+	{"GET", "/teams/:id/repos/:owner/:repo"},
+	{"PUT", "/teams/:id/repos/:owner/:repo"},
+	{"DELETE", "/teams/:id/repos/:owner/:repo"},
+	{"GET", "/user/teams"},
+
+	// Pull Requests
+	{"GET", "/repos/:owner/:repo/pulls"},
+	{"GET", "/repos/:owner/:repo/pulls/:number"},
+	{"POST", "/repos/:owner/:repo/pulls"},
+	{"GET", "/repos/:owner/:repo/pulls/:number/commits"},
+	{"GET", "/repos/:owner/:repo/pulls/:number/files"},
+	{"GET", "/repos/:owner/:repo/pulls/:number/merge"},
+	{"PUT", "/repos/:owner/:repo/pulls/:number/merge"},
+	{"GET", "/repos/:owner/:repo/pulls/:number/comments"},
+	{"PUT", "/repos/:owner/:repo/pulls/:number/comments"},
+
+	// Repositories
+	{"GET", "/user/repos"},
+	{"GET", "/users/:user/repos"},
+	{"GET", "/orgs/:org/repos"},
+	{"GET", "/repositories"},
+	{"POST", "/user/repos"},
+	{"POST", "/orgs/:org/repos"},
+	{"GET", "/repos/:owner/:repo"},
+	{"GET", "/repos/:owner/:repo/contributors"},
+	{"GET", "/repos/:owner/:repo/languages"},
+	{"GET", "/repos/:owner/:repo/teams"},
+	{"GET", "/repos/:owner/:repo/tags"},
+	{"GET", "/repos/:owner/:repo/branches"},
+	{"GET", "/repos/:owner/:repo/branches/:branch"},
+	{"DELETE", "/repos/:owner/:repo"},
+	{"GET", "/repos/:owner/:repo/collaborators"},
+	{"GET", "/repos/:owner/:repo/collaborators/:user"},
+	{"PUT", "/repos/:owner/:repo/collaborators/:user"},
+	{"DELETE", "/repos/:owner/:repo/collaborators/:user"},
+	{"GET", "/repos/:owner/:repo/comments"},
+	{"GET", "/repos/:owner/:repo/commits/:sha/comments"},
+	{"POST", "/repos/:owner/:repo/commits/:sha/comments"},
+	{"GET", "/repos/:owner/:repo/comments/:id"},
+	{"DELETE", "/repos/:owner/:repo/comments/:id"},
+	{"GET", "/repos/:owner/:repo/commits"},
+	{"GET", "/repos/:owner/:repo/commits/:sha"},
+	{"GET", "/repos/:owner/:repo/readme"},
+	{"GET", "/repos/:owner/:repo/contents/*path"},
+	{"DELETE", "/repos/:owner/:repo/contents/*path"},
+	{"GET", "/repos/:owner/:repo/:archive_format/:ref"},
+	{"GET", "/repos/:owner/:repo/keys"},
+	{"GET", "/repos/:owner/:repo/keys/:id"},
+	{"POST", "/repos/:owner/:repo/keys"},
+	{"DELETE", "/repos/:owner/:repo/keys/:id"},
+	{"GET", "/repos/:owner/:repo/downloads"},
+	{"GET", "/repos/:owner/:repo/downloads/:id"},
+	{"DELETE", "/repos/:owner/:repo/downloads/:id"},
+	{"GET", "/repos/:owner/:repo/forks"},
+	{"POST", "/repos/:owner/:repo/forks"},
+	{"GET", "/repos/:owner/:repo/hooks"},
+	{"GET", "/repos/:owner/:repo/hooks/:id"},
+	{"POST", "/repos/:owner/:repo/hooks"},
+	{"POST", "/repos/:owner/:repo/hooks/:id/tests"},
+	{"DELETE", "/repos/:owner/:repo/hooks/:id"},
+	{"POST", "/repos/:owner/:repo/merges"},
+	{"GET", "/repos/:owner/:repo/releases"},
+	{"GET", "/repos/:owner/:repo/releases/:id"},
+	{"POST", "/repos/:owner/:repo/releases"},
+	{"DELETE", "/repos/:owner/:repo/releases/:id"},
+	{"GET", "/repos/:owner/:repo/releases/:id/assets"},
+	{"GET", "/repos/:owner/:repo/stats/contributors"},
+	{"GET", "/repos/:owner/:repo/stats/commit_activity"},
+	{"GET", "/repos/:owner/:repo/stats/code_frequency"},
+	{"GET", "/repos/:owner/:repo/stats/participation"},
+	{"GET", "/repos/:owner/:repo/stats/punch_card"},
+	{"GET", "/repos/:owner/:repo/statuses/:ref"},
+	{"POST", "/repos/:owner/:repo/statuses/:ref"},
+
+	// Search
+	{"GET", "/search/repositories"},
+	{"GET", "/search/code"},
+	{"GET", "/search/issues"},
+	{"GET", "/search/users"},
+	{"GET", "/legacy/issues/search/:owner/:repository/:state/:keyword"},
+	{"GET", "/legacy/repos/search/:keyword"},
+	{"GET", "/legacy/user/search/:keyword"},
+	{"GET", "/legacy/user/email/:email"},
+
+	// Users
+	{"GET", "/users/:user"},
+	{"GET", "/user"},
+	{"GET", "/users"},
+	{"GET", "/user/emails"},
+	{"POST", "/user/emails"},
+	{"DELETE", "/user/emails"},
+	{"GET", "/users/:user/followers"},
+	{"GET", "/user/followers"},
+	{"GET", "/users/:user/following"},
+	{"GET", "/user/following"},
+	{"GET", "/user/following/:user"},
+	{"GET", "/users/:user/following/:target_user"},
+	{"PUT", "/user/following/:user"},
+	{"DELETE", "/user/following/:user"},
+	{"GET", "/users/:user/keys"},
+	{"GET", "/user/keys"},
+	{"GET", "/user/keys/:id"},
+	{"POST", "/user/keys"},
+	{"DELETE", "/user/keys/:id"},
+}
+
+// gplusAPI is the Google+ API route set
+var gplusAPI = []benchRoute{
+	// People
+	{"GET", "/people/:userId"},
+	{"GET", "/people"},
+	{"GET", "/activities/:activityId/people/:collection"},
+	{"GET", "/people/:userId/people/:collection"},
+	{"GET", "/people/:userId/openIdConnect"},
+
+	// Activities
+	{"GET", "/people/:userId/activities/:collection"},
+	{"GET", "/activities/:activityId"},
+	{"GET", "/activities"},
+
+	// Comments
+	{"GET", "/activities/:activityId/comments"},
+	{"GET", "/comments/:commentId"},
+
+	// Moments
+	{"POST", "/people/:userId/moments/:collection"},
+	{"GET", "/people/:userId/moments/:collection"},
+	{"DELETE", "/moments/:id"},
+}
+
+// benchPattern converts an httprouter path to a mux pattern
+func benchPattern(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		switch {
+		case strings.HasPrefix(p, ":"):
+			parts[i] = "{" + p[1:] + ":[^/]+}"
+		case strings.HasPrefix(p, "*"):
+			parts[i] = "{" + p[1:] + ":.*}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// benchMux returns a mux with the routes given, and a request for each route.
+// As routes are matched in order and patterns match any suffix,
+// routes with more segments are added first (as they would be in an app).
+func benchMux(routes []benchRoute) (*Mux, []*http.Request) {
+	sorted := make([]benchRoute, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i].path, "/") > strings.Count(sorted[j].path, "/")
+	})
+
+	m := New()
+	var requests []*http.Request
+	for _, r := range sorted {
+		m.Add(benchPattern(r.path), handler).Methods(r.method)
+		requests = append(requests, httptest.NewRequest(r.method, r.path, nil))
+	}
+	return m, requests
+}
+
+// benchmarkMatch matches every route in the set
+func benchmarkMatch(b *testing.B, routes []benchRoute, entries int) {
+	MaxCacheEntries = entries
+	defer func() { MaxCacheEntries = 500 }()
+
+	m, requests := benchMux(routes)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range requests {
+			if m.Match(r) == nil {
+				b.Fatalf("error matching request:%s %s", r.Method, r.URL.Path)
+			}
+		}
+	}
+}
+
+// benchmarkParse parses params for a single request
+func benchmarkParse(b *testing.B, routes []benchRoute, method, path string) {
+	m, _ := benchMux(routes)
+	r := httptest.NewRequest(method, path, nil)
+	route := m.Match(r)
+	if route == nil {
+		b.Fatalf("error matching request:%s %s", method, path)
+	}
+
+	buf := make([]Param, 0, 8)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = route.ParseInto(r.URL.Path, buf[:0])
+	}
+}
+
+// benchmarkParams parses params for a single request routed by the mux
+func benchmarkParams(b *testing.B, routes []benchRoute, method, path string) {
+	m, _ := benchMux(routes)
+	r := httptest.NewRequest(method, path, nil)
+	route := m.Match(r)
+	if route == nil {
+		b.Fatalf("error matching request:%s %s", method, path)
+	}
+	r = withRoute(r, route)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := Params(r)
+		if err != nil {
+			b.Fatalf("error parsing params:%s", err)
+		}
+	}
+}
+
+func BenchmarkGithubMatch(b *testing.B)       { benchmarkMatch(b, githubAPI, 0) }
+func BenchmarkGithubMatchCached(b *testing.B) { benchmarkMatch(b, githubAPI, 500) }
+func BenchmarkGPlusMatch(b *testing.B)        { benchmarkMatch(b, gplusAPI, 0) }
+func BenchmarkGPlusMatchCached(b *testing.B)  { benchmarkMatch(b, gplusAPI, 500) }
+func BenchmarkGithubParseStatic(b *testing.B) { benchmarkParse(b, githubAPI, "GET", "/user/repos") }
+func BenchmarkGithubParse(b *testing.B) {
+	benchmarkParse(b, githubAPI, "GET", "/repos/julienschmidt/httprouter/stargazers")
+}
+func BenchmarkGPlusParse(b *testing.B) {
+	benchmarkParse(b, gplusAPI, "GET", "/people/118051310819094153327")
+}
+func BenchmarkGPlusParse2Params(b *testing.B) {
+	benchmarkParse(b, gplusAPI, "GET", "/people/118051310819094153327/activities/123456789")
+}
+func BenchmarkGithubParams(b *testing.B) {
+	benchmarkParams(b, githubAPI, "GET", "/repos/julienschmidt/httprouter/stargazers")
+}
+func BenchmarkGPlusParams(b *testing.B) {
+	benchmarkParams(b, gplusAPI, "GET", "/people/118051310819094153327")
+}
+func BenchmarkGPlusParams2Params(b *testing.B) {
+	benchmarkParams(b, gplusAPI, "GET", "/people/118051310819094153327/activities/123456789")
+}
+func BenchmarkGithubParamsStatic(b *testing.B) { benchmarkParams(b, githubAPI, "GET", "/user/repos") }
+
+// maxParamsAllocs is the allocation budget for Params with two url params,
+// for the params struct, maps, query values and a slice per value (currently 8).
+const maxParamsAllocs = 10
+
+// TestAllocationBudget tests the matcher stays within its allocation targets.
+func TestAllocationBudget(t *testing.T) {
+	for _, entries := range []int{0, 500} {
+		MaxCacheEntries = entries
+		m, requests := benchMux(githubAPI)
+		allocs := testing.AllocsPerRun(10, func() {
+			for _, r := range requests {
+				m.Match(r)
+			}
+		})
+		if allocs > 0 {
+			t.Errorf("bench: Match allocated with cache size %d got:%v", entries, allocs)
+		}
+	}
+	MaxCacheEntries = 500
+
+	m, _ := benchMux(gplusAPI)
+	r := httptest.NewRequest("GET", "/people/118051310819094153327/activities/123456789", nil)
+	route := m.Match(r)
+	if route == nil {
+		t.Fatalf("bench: failed to match route")
+	}
+
+	buf := make([]Param, 0, 8)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = route.ParseInto(r.URL.Path, buf[:0])
+	})
+	if allocs > 0 {
+		t.Errorf("bench: ParseInto allocated got:%v", allocs)
+	}
+	if len(buf) != 2 {
+		t.Errorf("bench: ParseInto wrong params got:%v", buf)
+	}
+
+	r = withRoute(r, route)
+	allocs = testing.AllocsPerRun(100, func() {
+		Params(r)
+	})
+	if allocs > maxParamsAllocs {
+		t.Errorf("bench: Params allocations over budget got:%v want:%d", allocs, maxParamsAllocs)
+	}
+}
+
+// TestBenchRoutes tests every route in the benchmark sets matches its own pattern.
+func TestBenchRoutes(t *testing.T) {
+	for _, routes := range [][]benchRoute{githubAPI, gplusAPI} {
+		m, requests := benchMux(routes)
+		for _, r := range requests {
+			route := m.Match(r)
+			if route == nil {
+				t.Errorf("bench: failed to match %s %s", r.Method, r.URL.Path)
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind sets the fields of the struct pointed to by v from the params.
+// Fields are named by their param tag, or their json tag, or the field name.
+// Absent params leave fields unchanged, and blank params leave non-string fields unchanged.
+//
+//	type Post struct {
+//		Title     string     `param:"title"`
+//		Status    string     `param:"status" enum:"draft,published"`
+//		Published time.Time  `param:"published" layout:"2006-01-02"`
+//		Author    *int64     `param:"author_id"` // nil if absent
+//		Tags      []Tag      `param:"tags"`      // from tags[0].name, tags[1].name...
+//	}
+//
+// time.Time fields are parsed with the layout tag, or time.RFC3339 if none.
+// Pointer fields are only set if the param is present, so absent and zero
+// values may be distinguished. The enum tag lists the values permitted.
+// Slices of structs are set from indexed keys like tags[0].name, and nested
+// structs from keys like author.name. Invalid params return a 400 StatusError.
+func (p *RequestParams) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("mux: bind requires a pointer to a struct, got %T", v)
+	}
+	return p.bindStruct("", rv.Elem())
+}
+
+// bindStruct sets the fields of the struct rv from params with keys prefix+name
+func (p *RequestParams) bindStruct(prefix string, rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		name := paramName(sf)
+		if name == "" {
+			continue
+		}
+		if err := p.bindField(prefix+name, sf, rv.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeType is the type of time.Time fields
+var timeType = reflect.TypeOf(time.Time{})
+
+// bindField sets the field v from the params for key
+func (p *RequestParams) bindField(key string, sf reflect.StructField, v reflect.Value) error {
+	t := v.Type()
+
+	switch {
+	case t.Kind() == reflect.Ptr:
+		if !p.present(key, t.Elem()) {
+			return nil
+		}
+		elem := reflect.New(t.Elem())
+		if err := p.bindField(key, sf, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+
+	case t.Kind() == reflect.Struct && t != timeType:
+		return p.bindStruct(key+".", v)
+
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct && t.Elem() != timeType:
+		indexes := p.indexes(key)
+		if len(indexes) == 0 {
+			return nil
+		}
+		s := reflect.MakeSlice(t, len(indexes), len(indexes))
+		for i, index := range indexes {
+			if err := p.bindStruct(key+"["+index+"].", s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+
+	case t.Kind() == reflect.Slice:
+		values := append(p.Values[key], p.Values[key+"[]"]...)
+		if len(values) == 0 {
+			return nil
+		}
+		s := reflect.MakeSlice(t, 0, len(values))
+		for _, value := range values {
+			elem := reflect.New(t.Elem()).Elem()
+			if err := bindValue(key, sf, elem, value); err != nil {
+				return err
+			}
+			s = reflect.Append(s, elem)
+		}
+		v.Set(s)
+		return nil
+	}
+
+	values, ok := p.Values[key]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	return bindValue(key, sf, v, values[0])
+}
+
+// present returns true if params are set for key and a field of type t
+func (p *RequestParams) present(key string, t reflect.Type) bool {
+	if _, ok := p.Values[key]; ok {
+		return true
+	}
+	if _, ok := p.Values[key+"[]"]; ok {
+		return true
+	}
+	if t.Kind() == reflect.Struct && t != timeType || t.Kind() == reflect.Slice {
+		for k := range p.Values {
+			if strings.HasPrefix(k, key+".") || strings.HasPrefix(k, key+"[") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// indexes returns the indexes used in keys like key[0].name in numeric order
+func (p *RequestParams) indexes(key string) []string {
+	seen := make(map[int]bool)
+	prefix := key + "["
+	for k := range p.Values {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		end := strings.Index(k[len(prefix):], "].")
+		if end < 0 {
+			continue
+		}
+		i, err := strconv.Atoi(k[len(prefix) : len(prefix)+end])
+		if err == nil && i >= 0 {
+			seen[i] = true
+		}
+	}
+
+	var ints []int
+	for i := range seen {
+		ints = append(ints, i)
+	}
+	sort.Ints(ints)
+
+	indexes := make([]string, len(ints))
+	for i, index := range ints {
+		indexes[i] = strconv.Itoa(index)
+	}
+	return indexes
+}
+
+// bindValue sets v from the string s, checking it against any enum tag
+func bindValue(key string, sf reflect.StructField, v reflect.Value, s string) error {
+	if enum := sf.Tag.Get("enum"); enum != "" && s != "" && !enumContains(enum, s) {
+		return bindError(key, "must be one of "+enum)
+	}
+
+	// Blank values leave fields other than strings unchanged
+	if s == "" && v.Kind() != reflect.String {
+		return nil
+	}
+
+	switch {
+	case v.Type() == timeType:
+		layout := sf.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		tm, err := time.Parse(layout, s)
+		if err != nil {
+			return bindError(key, "must be a time in the format "+layout)
+		}
+		v.Set(reflect.ValueOf(tm))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		switch strings.ToLower(s) {
+		case "on", "yes":
+			v.SetBool(true)
+		case "off", "no":
+			v.SetBool(false)
+		default:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return bindError(key, "must be true or false")
+			}
+			v.SetBool(b)
+		}
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return bindError(key, "must be an integer")
+		}
+		v.SetInt(i)
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return bindError(key, "must be a positive integer")
+		}
+		v.SetUint(i)
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return bindError(key, "must be a number")
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("mux: bind does not support %s for %s", v.Type(), key)
+	}
+	return nil
+}
+
+// paramName returns the name of the param for a field, or "" if it is skipped
+func paramName(sf reflect.StructField) string {
+	name := sf.Tag.Get("param")
+	if name == "" {
+		name = strings.Split(sf.Tag.Get("json"), ",")[0]
+	}
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	return name
+}
+
+// enumContains returns true if the comma separated list enum contains s
+func enumContains(enum, s string) bool {
+	for _, e := range strings.Split(enum, ",") {
+		if strings.TrimSpace(e) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bindError returns a 400 StatusError for an invalid param
+func bindError(key, msg string) error {
+	return NewStatusError(http.StatusBadRequest, fmt.Errorf("mux: param %s %s", key, msg))
+}
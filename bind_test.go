@@ -0,0 +1,86 @@
+package mux
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type bindTag struct {
+	Name  string `param:"name"`
+	Color string `param:"color" enum:"red,green"`
+}
+
+type bindPost struct {
+	Title     string    `param:"title"`
+	Status    string    `param:"status" enum:"draft,published"`
+	Published time.Time `param:"published" layout:"2006-01-02"`
+	Updated   time.Time `json:"updated"`
+	Author    *int64    `param:"author_id"`
+	Editor    *int64    `param:"editor_id"`
+	Note      *string   `param:"note"`
+	Public    bool      `param:"public"`
+	Score     float64
+	IDs       []int64   `param:"ids"`
+	Tags      []bindTag `param:"tags"`
+	Skip      string    `param:"-"`
+}
+
+// TestBind tests binding params to structs.
+func TestBind(t *testing.T) {
+	p := &RequestParams{Values: url.Values{
+		"title":         {"Hello"},
+		"status":        {"draft"},
+		"published":     {"2017-01-02"},
+		"updated":       {"2017-01-02T10:00:00Z"},
+		"author_id":     {"3"},
+		"note":          {""},
+		"public":        {"on"},
+		"Score":         {"1.5"},
+		"ids[]":         {"1", "2"},
+		"tags[1].name":  {"b"},
+		"tags[0].name":  {"a"},
+		"tags[0].color": {"red"},
+		"-":             {"x"},
+	}}
+
+	var post bindPost
+	if err := p.Bind(&post); err != nil {
+		t.Fatalf("bind: error binding:%s", err)
+	}
+
+	if post.Title != "Hello" || post.Status != "draft" || !post.Public || post.Score != 1.5 || post.Skip != "" {
+		t.Errorf("bind: wrong values got:%+v", post)
+	}
+	if !post.Published.Equal(time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)) || post.Updated.Hour() != 10 {
+		t.Errorf("bind: wrong times got:%s %s", post.Published, post.Updated)
+	}
+	if post.Author == nil || *post.Author != 3 || post.Editor != nil || post.Note == nil || *post.Note != "" {
+		t.Errorf("bind: wrong pointers got:%v %v %v", post.Author, post.Editor, post.Note)
+	}
+	if len(post.IDs) != 2 || post.IDs[1] != 2 {
+		t.Errorf("bind: wrong ids got:%v", post.IDs)
+	}
+	if len(post.Tags) != 2 || post.Tags[0].Name != "a" || post.Tags[0].Color != "red" || post.Tags[1].Name != "b" {
+		t.Errorf("bind: wrong tags got:%+v", post.Tags)
+	}
+
+	// Test invalid params give a 400 error
+	invalid := []url.Values{
+		{"status": {"deleted"}},
+		{"published": {"02/01/2017"}},
+		{"author_id": {"x"}},
+		{"tags[0].color": {"blue"}},
+	}
+	for _, values := range invalid {
+		err := (&RequestParams{Values: values}).Bind(&bindPost{})
+		if ErrorStatus(err) != http.StatusBadRequest {
+			t.Errorf("bind: expected 400 for %v got:%v", values, err)
+		}
+	}
+
+	if err := p.Bind(post); err == nil {
+		t.Errorf("bind: expected error binding to non pointer")
+	}
+}
@@ -0,0 +1,113 @@
+package mux
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// cacheKey is the key for cached routes, routes are cached by method and path
+// so that routes with identical patterns but different methods do not collide.
+type cacheKey struct {
+	method string
+	path   string
+}
+
+// cacheEntry is an entry in the cache, referenced is set when it is used
+type cacheEntry struct {
+	key        cacheKey
+	route      Route
+	referenced atomic.Bool
+}
+
+// routeCache is a cache of routes matched for requests which approximates
+// least recently used eviction with the clock algorithm, so that lookups need
+// only a read lock and do not serialise concurrent requests.
+// The size is limited by MaxCacheEntries, and the cache is reset whenever
+// the route table changes.
+type routeCache struct {
+	mu      sync.RWMutex
+	entries map[cacheKey]*cacheEntry
+	ring    []*cacheEntry
+	hand    int
+}
+
+// newRouteCache returns a new empty route cache
+func newRouteCache() *routeCache {
+	return &routeCache{
+		entries: make(map[cacheKey]*cacheEntry),
+	}
+}
+
+// get returns the route cached for key and marks it as recently used
+func (c *routeCache) get(key cacheKey) (Route, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !e.referenced.Load() {
+		e.referenced.Store(true)
+	}
+	return e.route, true
+}
+
+// add stores the route for key, evicting entries which have not been
+// used recently if the cache holds more than max entries.
+func (c *routeCache) add(key cacheKey, route Route, max int) {
+	if max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.route = route
+		e.referenced.Store(true)
+		return
+	}
+
+	// Shrink the cache if max has been reduced
+	for len(c.ring) > max {
+		delete(c.entries, c.ring[len(c.ring)-1].key)
+		c.ring = c.ring[:len(c.ring)-1]
+	}
+	if c.hand >= len(c.ring) {
+		c.hand = 0
+	}
+
+	e := &cacheEntry{key: key, route: route}
+	c.entries[key] = e
+	if len(c.ring) < max {
+		c.ring = append(c.ring, e)
+		return
+	}
+
+	// Advance the hand, clearing referenced entries, until an entry
+	// which has not been used since the last pass is found to replace
+	for {
+		old := c.ring[c.hand]
+		if !old.referenced.Swap(false) {
+			delete(c.entries, old.key)
+			c.ring[c.hand] = e
+			c.hand = (c.hand + 1) % len(c.ring)
+			return
+		}
+		c.hand = (c.hand + 1) % len(c.ring)
+	}
+}
+
+// reset removes all entries from the cache
+func (c *routeCache) reset() {
+	c.mu.Lock()
+	c.entries = make(map[cacheKey]*cacheEntry)
+	c.ring = nil
+	c.hand = 0
+	c.mu.Unlock()
+}
+
+// len returns the number of entries in the cache
+func (c *routeCache) len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.ring)
+}
@@ -0,0 +1,152 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestRouteCacheEviction tests entries not used recently are evicted.
+func TestRouteCacheEviction(t *testing.T) {
+	c := newRouteCache()
+	routes := make([]Route, 4)
+	for i := range routes {
+		routes[i], _ = NewRoute(fmt.Sprintf("/%d", i), handler)
+	}
+
+	c.add(cacheKey{"GET", "/0"}, routes[0], 3)
+	c.add(cacheKey{"GET", "/1"}, routes[1], 3)
+	c.add(cacheKey{"GET", "/2"}, routes[2], 3)
+
+	// Use 0 so that 1 is the first entry not used recently
+	c.get(cacheKey{"GET", "/0"})
+	c.add(cacheKey{"GET", "/3"}, routes[3], 3)
+
+	if c.len() != 3 {
+		t.Errorf("cache: wrong length got:%d want:3", c.len())
+	}
+	if _, ok := c.get(cacheKey{"GET", "/1"}); ok {
+		t.Errorf("cache: least recently used entry not evicted")
+	}
+	if r, ok := c.get(cacheKey{"GET", "/0"}); !ok || r != routes[0] {
+		t.Errorf("cache: recently used entry evicted")
+	}
+}
+
+// TestRouteCacheConcurrent tests the cache may be used concurrently, and shrinks if max is reduced.
+func TestRouteCacheConcurrent(t *testing.T) {
+	c := newRouteCache()
+	route, _ := NewRoute("/", handler)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := cacheKey{"GET", fmt.Sprintf("/%d", (i*j)%20)}
+				if _, ok := c.get(key); !ok {
+					c.add(key, route, 10)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if c.len() != 10 {
+		t.Errorf("cache: wrong length got:%d want:10", c.len())
+	}
+
+	c.add(cacheKey{"GET", "/new"}, route, 5)
+	if c.len() != 5 {
+		t.Errorf("cache: not shrunk got:%d want:5", c.len())
+	}
+	if _, ok := c.get(cacheKey{"GET", "/new"}); !ok {
+		t.Errorf("cache: entry not added after shrinking")
+	}
+}
+
+// TestRouteCacheInvalidation tests the cache is reset when routes are added.
+func TestRouteCacheInvalidation(t *testing.T) {
+	MaxCacheEntries = 500
+	m := New()
+	m.Get(`/pages/{id:\d+}`, showHandler)
+	m.Post(`/pages/{id:\d+}`, updateHandler)
+
+	get := m.Match(httptest.NewRequest(http.MethodGet, "/pages/1", nil))
+	post := m.Match(httptest.NewRequest(http.MethodPost, "/pages/1", nil))
+	if get == nil || post == nil || get == post {
+		t.Fatalf("cache: routes with different methods collided")
+	}
+	if m.cache.len() != 2 {
+		t.Errorf("cache: wrong length got:%d want:2", m.cache.len())
+	}
+
+	m.Get("/other", showHandler)
+	if m.cache.len() != 0 {
+		t.Errorf("cache: not reset on adding route")
+	}
+}
+
+// largeRoutes returns a mux with n resources of 4 routes each, and requests for the last resource
+func largeRoutes(n int) (*Mux, []*http.Request) {
+	m := New()
+	var requests []*http.Request
+	for i := 0; i < n; i++ {
+		m.Get(fmt.Sprintf(`/resource%d`, i), listHandler)
+		m.Get(fmt.Sprintf(`/resource%d/{id:\d+}`, i), showHandler)
+		m.Post(fmt.Sprintf(`/resource%d/{id:\d+}/update`, i), updateHandler)
+		m.Post(fmt.Sprintf(`/resource%d/{id:\d+}/destroy`, i), destroyHandler)
+	}
+	last := n - 1
+	requests = append(requests,
+		httptest.NewRequest(http.MethodGet, fmt.Sprintf("/resource%d", last), nil),
+		httptest.NewRequest(http.MethodGet, fmt.Sprintf("/resource%d/99", last), nil),
+		httptest.NewRequest(http.MethodPost, fmt.Sprintf("/resource%d/99/update", last), nil),
+		httptest.NewRequest(http.MethodPost, fmt.Sprintf("/resource%d/99/destroy", last), nil),
+	)
+	return m, requests
+}
+
+// benchmarkLargeRoutes matches requests against a large route table
+func benchmarkLargeRoutes(b *testing.B, entries int) {
+	MaxCacheEntries = entries
+	defer func() { MaxCacheEntries = 500 }()
+
+	m, requests := largeRoutes(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range requests {
+			if m.Match(r) == nil {
+				b.Fatalf("error matching request:%s", r.URL.Path)
+			}
+		}
+	}
+}
+
+// go test -test.bench BenchmarkLargeRoutes -benchmem
+func BenchmarkLargeRoutesUncached(b *testing.B) {
+	benchmarkLargeRoutes(b, 0)
+}
+
+func BenchmarkLargeRoutesCached(b *testing.B) {
+	benchmarkLargeRoutes(b, 500)
+}
+
+// go test -test.bench BenchmarkLargeRoutesCachedParallel -cpu 1,4,8
+func BenchmarkLargeRoutesCachedParallel(b *testing.B) {
+	m, requests := largeRoutes(100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for _, r := range requests {
+				if m.Match(r) == nil {
+					b.Fatalf("error matching request:%s", r.URL.Path)
+				}
+			}
+		}
+	})
+}
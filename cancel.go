@@ -0,0 +1,64 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the (non-standard) status used for requests
+// cancelled by the client before a response is sent.
+const StatusClientClosedRequest = 499
+
+// Cancelled returns nil if the request context is still active, or a StatusError
+// if it has been cancelled by the client (499) or its deadline has passed (504).
+// Handlers performing expensive work may call this to stop early:
+//
+//	if err := mux.Cancelled(r); err != nil {
+//	  return err
+//	}
+func Cancelled(r *http.Request) error {
+	err := r.Context().Err()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewStatusError(http.StatusGatewayTimeout, err)
+	}
+	return NewStatusError(StatusClientClosedRequest, err)
+}
+
+// ContextReader returns a reader which returns the context error
+// from Read once ctx is done, so that reading a large body is
+// aborted promptly when the request is cancelled.
+func ContextReader(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	return &contextReader{ctx: ctx, ReadCloser: rc}
+}
+
+// contextReader checks the context before each read
+type contextReader struct {
+	ctx context.Context
+	io.ReadCloser
+}
+
+// Read reads from the underlying reader unless the context is done
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.ReadCloser.Read(p)
+}
+
+// readError returns the cancellation error for the request if it has been
+// cancelled, a 413 StatusError if the body was too large, and err otherwise
+func readError(r *http.Request, err error) error {
+	if cerr := Cancelled(r); cerr != nil {
+		return cerr
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return NewStatusError(http.StatusRequestEntityTooLarge, err)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package mux
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cancellingReader cancels the request context after the first read
+type cancellingReader struct {
+	cancel context.CancelFunc
+	reads  int
+}
+
+func (c *cancellingReader) Read(p []byte) (int, error) {
+	c.reads++
+	c.cancel()
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+// TestParamsCancelled tests params parsing stops when the request is cancelled.
+func TestParamsCancelled(t *testing.T) {
+	m := New()
+	route := m.Post("/upload", handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	body := &cancellingReader{cancel: cancel}
+	r := httptest.NewRequest(http.MethodPost, "/upload", io.LimitReader(body, 100<<20)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = withRoute(r, route)
+
+	_, err := Params(r)
+	if ErrorStatus(err) != StatusClientClosedRequest {
+		t.Errorf("cancel: wrong error for cancelled request got:%v", err)
+	}
+	if body.reads > 1 {
+		t.Errorf("cancel: body read after cancellation got:%d reads", body.reads)
+	}
+}
+
+// TestCancelled tests the Cancelled helper.
+func TestCancelled(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if Cancelled(r) != nil {
+		t.Errorf("cancel: active request reported cancelled")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if ErrorStatus(Cancelled(r.WithContext(ctx))) != http.StatusGatewayTimeout {
+		t.Errorf("cancel: wrong status for deadline exceeded")
+	}
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if ErrorStatus(Cancelled(r.WithContext(cancelled))) != StatusClientClosedRequest {
+		t.Errorf("cancel: wrong status for cancelled request")
+	}
+
+	// Handlers are not called for cancelled requests
+	m := New()
+	called := false
+	m.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		called = true
+		return nil
+	})
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", strings.NewReader("")).WithContext(ctx))
+	if called || w.Code != StatusClientClosedRequest {
+		t.Errorf("cancel: handler called for cancelled request got:%d", w.Code)
+	}
+}
+
+// TestParamsTooLarge tests bodies over a MaxBytesReader limit give a 413 error.
+func TestParamsTooLarge(t *testing.T) {
+	m := New()
+	m.Post("/", func(w http.ResponseWriter, r *http.Request) error {
+		_, err := Params(r)
+		return err
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name="+strings.Repeat("a", 100)))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Body = http.MaxBytesReader(w, r.Body, 10)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("params: wrong status for large body got:%d", w.Code)
+	}
+}
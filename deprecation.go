@@ -0,0 +1,48 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// DeprecatedSeries is the series name for values logged for requests to deprecated routes
+var DeprecatedSeries = "deprecated"
+
+// Deprecation describes a deprecated route, set with Route.Deprecated.
+type Deprecation struct {
+	// Sunset is the time after which the route will be removed
+	Sunset time.Time
+	// Link points to documentation on migrating away from the route
+	Link string
+}
+
+// write adds the Deprecation, Sunset (RFC 8594) and Link headers to the response,
+// and logs the use of the route with log.Values, so that owners can see which
+// callers remain before the route is removed.
+func (d *Deprecation) write(w http.ResponseWriter, r *http.Request, route Route) {
+	w.Header().Set("Deprecation", "true")
+	if !d.Sunset.IsZero() {
+		w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		w.Header().Add("Link", "<"+d.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+
+	values := map[string]interface{}{
+		log.SeriesName: DeprecatedSeries,
+		"route":        route.Pattern(),
+		"method":       r.Method,
+		"user_agent":   r.UserAgent(),
+		"count":        1,
+	}
+	if !d.Sunset.IsZero() {
+		values["sunset"] = d.Sunset.UTC().Format(time.RFC3339)
+	}
+	if user, ok := Value[interface{}](r, KeyUser); ok && user != nil {
+		values["user"] = fmt.Sprint(user)
+	}
+	log.Values(values)
+}
@@ -0,0 +1,62 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// valuesRecorder records values sent to it
+type valuesRecorder struct {
+	mu     sync.Mutex
+	values []map[string]interface{}
+}
+
+func (v *valuesRecorder) Values(values map[string]interface{}) {
+	v.mu.Lock()
+	v.values = append(v.values, values)
+	v.mu.Unlock()
+}
+
+func (v *valuesRecorder) ValuesBatch(values []map[string]interface{}) {
+	for _, vs := range values {
+		v.Values(vs)
+	}
+}
+
+// TestDeprecated tests deprecated routes send headers and log their use.
+func TestDeprecated(t *testing.T) {
+	rec := &valuesRecorder{}
+	log.AddValuesLogger(rec, log.SeriesFilter(DeprecatedSeries))
+	defer log.RemoveValuesLogger(rec)
+
+	sunset := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	m := New()
+	m.Get("/v1/users", handler).Deprecated(sunset, "https://example.com/v2")
+	m.Get("/v2/users", handler)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	if w.Header().Get("Deprecation") != "true" || w.Header().Get("Sunset") != "Wed, 02 Jan 2030 00:00:00 GMT" {
+		t.Errorf("deprecated: wrong headers got:%v", w.Header())
+	}
+	if w.Header().Get("Link") != `<https://example.com/v2>; rel="deprecation"; type="text/html"` {
+		t.Errorf("deprecated: wrong link got:%s", w.Header().Get("Link"))
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/users", nil))
+	if w.Header().Get("Deprecation") != "" {
+		t.Errorf("deprecated: headers sent for current route got:%v", w.Header())
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.values) != 1 || rec.values[0]["route"] != "/v1/users" || rec.values[0]["count"] != 1 {
+		t.Errorf("deprecated: wrong values logged got:%v", rec.values)
+	}
+}
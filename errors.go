@@ -0,0 +1,41 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+)
+
+// StatusError is an error with an associated http status code,
+// handlers may return one to inform the ErrorHandler which status to send.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+// NewStatusError returns a new StatusError with the status and error given.
+func NewStatusError(status int, err error) *StatusError {
+	return &StatusError{Status: status, Err: err}
+}
+
+// Error returns the underlying error string, or the status text if none.
+func (e *StatusError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorStatus returns the http status for err, which is the status of
+// the first StatusError in the chain, or 500 for other errors.
+func ErrorStatus(err error) int {
+	var se *StatusError
+	if errors.As(err, &se) && se.Status != 0 {
+		return se.Status
+	}
+	return http.StatusInternalServerError
+}
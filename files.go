@@ -0,0 +1,300 @@
+package mux
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheImmutable is a Cache-Control value suitable for fingerprinted assets
+// which never change at a given url.
+const CacheImmutable = "public, max-age=31536000, immutable"
+
+// CacheNone is a Cache-Control value which requires revalidation of every request.
+const CacheNone = "no-cache"
+
+// CacheNoStore is a Cache-Control value which prevents caching entirely, used in development.
+const CacheNoStore = "no-store"
+
+// FileServer serves static files from a filesystem, setting Last-Modified and ETag
+// headers and honouring conditional (If-Modified-Since, If-None-Match) and Range requests.
+// Cache-Control headers may be set per path pattern with SetCacheControl.
+type FileServer struct {
+	// FS is the filesystem files are served from.
+	FS fs.FS
+
+	// CacheControl is the default Cache-Control value, if blank no header is set.
+	CacheControl string
+
+	// NotFound is called if no file is found for the request.
+	NotFound HandlerFunc
+
+	// AutoIndex enables directory listings for directories without an index.html.
+	// It is off by default, use AutoIndexPrefixes to enable it only for some paths.
+	AutoIndex bool
+
+	// AutoIndexPrefixes enables directory listings only for paths with these prefixes.
+	AutoIndexPrefixes []string
+
+	// IndexTemplate renders directory listings, it is passed an Index.
+	IndexTemplate *template.Template
+
+	// Dev disables caching for local development, files are served
+	// with Cache-Control: no-store and without ETag or Last-Modified,
+	// so that browsers always fetch the latest version.
+	Dev bool
+
+	// cacheRules are evaluated in order to find Cache-Control for a path
+	cacheRules []cacheRule
+}
+
+// cacheRule stores a Cache-Control value for paths matching pattern
+type cacheRule struct {
+	pattern string
+	value   string
+}
+
+// NewFileServer returns a new FileServer serving files from the root directory given.
+// Use it as the mux FileHandler with:
+// m.FileHandler = mux.NewFileServer("public").ServeFile
+func NewFileServer(root string) *FileServer {
+	return NewFileServerFS(os.DirFS(root))
+}
+
+// NewFileServerFS returns a new FileServer serving files from fsys.
+func NewFileServerFS(fsys fs.FS) *FileServer {
+	return &FileServer{
+		FS:            fsys,
+		NotFound:      fileHandler,
+		IndexTemplate: defaultIndexTemplate,
+	}
+}
+
+// SetCacheControl sets the Cache-Control value for request paths matching pattern.
+// Patterns use the path.Match syntax and are matched against the request path,
+// a pattern ending in / matches all paths below it. Rules are evaluated in the order
+// they were added, for example:
+// f.SetCacheControl("/assets/*-*.css", mux.CacheImmutable)
+// f.SetCacheControl("/assets/", "public, max-age=3600")
+func (f *FileServer) SetCacheControl(pattern, value string) {
+	f.cacheRules = append(f.cacheRules, cacheRule{pattern: pattern, value: value})
+}
+
+// cacheControl returns the Cache-Control value for this path.
+func (f *FileServer) cacheControl(p string) string {
+	for _, rule := range f.cacheRules {
+		if strings.HasSuffix(rule.pattern, "/") {
+			if strings.HasPrefix(p, rule.pattern) {
+				return rule.value
+			}
+			continue
+		}
+		if ok, _ := path.Match(rule.pattern, p); ok {
+			return rule.value
+		}
+	}
+	return f.CacheControl
+}
+
+// ServeFile serves the file at the request path, it conforms to HandlerFunc
+// so that it can be used as the mux FileHandler.
+func (f *FileServer) ServeFile(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return f.NotFound(w, r)
+	}
+
+	return f.servePath(w, r, r.URL.Path)
+}
+
+// servePath serves the file at path p, which may differ from the request path.
+func (f *FileServer) servePath(w http.ResponseWriter, r *http.Request, p string) error {
+	// Clean the path to remove any attempts to escape the root
+	p = path.Clean("/" + p)
+
+	file, stat, err := f.open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+			return f.NotFound(w, r)
+		}
+		return err
+	}
+	defer file.Close()
+
+	// Directories are only returned by open if listings are enabled
+	if stat.IsDir() {
+		return f.serveIndex(w, r, p)
+	}
+
+	return f.serveContent(w, r, p, file, stat)
+}
+
+// open opens the file at path p, falling back to index.html for directories.
+func (f *FileServer) open(p string) (fs.File, fs.FileInfo, error) {
+	name := strings.TrimPrefix(p, "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return nil, nil, fs.ErrInvalid
+	}
+
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	// Serve index.html for directories, and the directory itself
+	// only if no index is found and listings are enabled for this path
+	if stat.IsDir() {
+		index, indexStat, err := f.open(path.Join(p, "index.html"))
+		if err == nil || !f.autoIndex(p) {
+			file.Close()
+			return index, indexStat, err
+		}
+	}
+
+	return file, stat, nil
+}
+
+// serveContent writes the file with cache headers, using http.ServeContent
+// to handle conditional and range requests.
+func (f *FileServer) serveContent(w http.ResponseWriter, r *http.Request, p string, file fs.File, stat fs.FileInfo) error {
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		// Some filesystems do not support seeking, so read the file into memory
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	if f.Dev {
+		w.Header().Set("Cache-Control", CacheNoStore)
+		http.ServeContent(w, r, stat.Name(), time.Time{}, content)
+		return nil
+	}
+
+	if cc := f.cacheControl(p); cc != "" {
+		w.Header().Set("Cache-Control", cc)
+	}
+	w.Header().Set("ETag", fileETag(stat))
+
+	// ServeContent sets Last-Modified and Content-Type,
+	// and replies to conditional and range requests
+	http.ServeContent(w, r, stat.Name(), stat.ModTime(), content)
+	return nil
+}
+
+// fileETag returns an etag derived from file modification time and size.
+func fileETag(stat fs.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, stat.ModTime().UnixNano(), stat.Size())
+}
+
+// Index is passed to the IndexTemplate to render directory listings.
+type Index struct {
+	Path    string
+	Entries []IndexEntry
+}
+
+// IndexEntry describes one file or directory within an Index.
+type IndexEntry struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// defaultIndexTemplate renders a simple html directory listing.
+var defaultIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+{{if ne .Path "/"}}<tr><td><a href="../">../</a></td><td></td><td></td></tr>{{end}}
+{{range .Entries}}<tr><td><a href="{{.Name}}{{if .IsDir}}/{{end}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if not .IsDir}}{{.Size}}{{end}}</td><td>{{.ModTime.Format "2006-01-02 15:04"}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// autoIndex returns true if directory listings are enabled for this path.
+func (f *FileServer) autoIndex(p string) bool {
+	if f.AutoIndex {
+		return true
+	}
+	for _, prefix := range f.AutoIndexPrefixes {
+		if strings.HasPrefix(p+"/", prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveIndex renders a listing of the directory at path p using IndexTemplate.
+func (f *FileServer) serveIndex(w http.ResponseWriter, r *http.Request, p string) error {
+	// Redirect to a trailing slash so that relative links in the listing work
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, path.Clean(r.URL.Path)+"/", http.StatusMovedPermanently)
+		return nil
+	}
+
+	name := strings.TrimPrefix(p, "/")
+	if name == "" {
+		name = "."
+	}
+	entries, err := fs.ReadDir(f.FS, name)
+	if err != nil {
+		return err
+	}
+
+	index := Index{Path: path.Clean(p + "/")}
+	for _, e := range entries {
+		// Never list hidden files
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		index.Entries = append(index.Entries, IndexEntry{
+			Name:    e.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   e.IsDir(),
+		})
+	}
+
+	// Sort directories first, then by name
+	sort.Slice(index.Entries, func(i, j int) bool {
+		if index.Entries[i].IsDir != index.Entries[j].IsDir {
+			return index.Entries[i].IsDir
+		}
+		return index.Entries[i].Name < index.Entries[j].Name
+	})
+
+	if f.Dev {
+		w.Header().Set("Cache-Control", CacheNoStore)
+	} else if cc := f.cacheControl(p); cc != "" {
+		w.Header().Set("Cache-Control", cc)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return f.IndexTemplate.Execute(w, index)
+}
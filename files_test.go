@@ -0,0 +1,140 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testFiles = fstest.MapFS{
+	"index.html":            {Data: []byte("<h1>index</h1>"), ModTime: time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)},
+	"assets/app-1a2b3c.css": {Data: []byte("body{color:red}"), ModTime: time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)},
+	"assets/app.js":         {Data: []byte("console.log('app')"), ModTime: time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)},
+}
+
+// TestFileServer tests serving files with cache headers.
+func TestFileServer(t *testing.T) {
+	f := NewFileServerFS(testFiles)
+	f.CacheControl = CacheNone
+	f.SetCacheControl("/assets/*-*.css", CacheImmutable)
+
+	// Test index
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	err := f.ServeFile(w, r)
+	if err != nil || w.Code != http.StatusOK || w.Body.String() != "<h1>index</h1>" {
+		t.Errorf("files: failed to serve index got:%d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") != CacheNone {
+		t.Errorf("files: wrong cache control for index:%s", w.Header().Get("Cache-Control"))
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Last-Modified") == "" {
+		t.Errorf("files: missing etag or last modified headers:%v", w.Header())
+	}
+
+	// Test fingerprinted assets are immutable
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/assets/app-1a2b3c.css", nil)
+	f.ServeFile(w, r)
+	if w.Header().Get("Cache-Control") != CacheImmutable {
+		t.Errorf("files: wrong cache control for asset:%s", w.Header().Get("Cache-Control"))
+	}
+
+	// Test conditional requests
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/index.html", nil)
+	r.Header.Set("If-None-Match", etag)
+	f.ServeFile(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("files: If-None-Match failed got:%d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	r.Header.Set("If-Modified-Since", time.Date(2017, 2, 2, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+	f.ServeFile(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("files: If-Modified-Since failed got:%d", w.Code)
+	}
+
+	// Test range requests
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	r.Header.Set("Range", "bytes=0-6")
+	f.ServeFile(w, r)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "console" {
+		t.Errorf("files: range failed got:%d %s", w.Code, w.Body.String())
+	}
+
+	// Test missing files and attempts to escape root
+	for _, p := range []string{"/missing.css", "/../files.go", "/assets/../../files.go"} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.Path = p
+		f.ServeFile(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("files: expected not found for %s got:%d", p, w.Code)
+		}
+	}
+}
+
+// TestFileServerDev tests dev mode disables caching and validators.
+func TestFileServerDev(t *testing.T) {
+	f := NewFileServerFS(testFiles)
+	f.SetCacheControl("/assets/*-*.css", CacheImmutable)
+	f.Dev = true
+
+	for _, p := range []string{"/", "/assets/app-1a2b3c.css"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, p, nil)
+		r.Header.Set("If-Modified-Since", time.Date(2017, 2, 2, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		f.ServeFile(w, r)
+		if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != CacheNoStore {
+			t.Errorf("files: dev mode failed for %s got:%d %s", p, w.Code, w.Header().Get("Cache-Control"))
+		}
+		if w.Header().Get("ETag") != "" || w.Header().Get("Last-Modified") != "" {
+			t.Errorf("files: dev mode sent validators for %s:%v", p, w.Header())
+		}
+	}
+}
+
+// TestFileServerAutoIndex tests directory listings are off by default and may be enabled per prefix.
+func TestFileServerAutoIndex(t *testing.T) {
+	f := NewFileServerFS(testFiles)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/assets/", nil)
+	f.ServeFile(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("files: listed directory by default got:%d", w.Code)
+	}
+
+	f.AutoIndexPrefixes = []string{"/assets/"}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/assets", nil)
+	f.ServeFile(w, r)
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("files: failed to redirect directory got:%d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/assets/", nil)
+	f.ServeFile(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `href="app.js"`) {
+		t.Errorf("files: failed to list directory got:%d %s", w.Code, w.Body.String())
+	}
+
+	// Index files take precedence over listings
+	f.AutoIndex = true
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	f.ServeFile(w, r)
+	if w.Body.String() != "<h1>index</h1>" {
+		t.Errorf("files: listed directory with index got:%s", w.Body.String())
+	}
+}
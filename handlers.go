@@ -19,15 +19,16 @@ func fileHandler(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-// errHandler is a simple built-in error handler which writes the error string to context.Writer
+// errHandler is a simple built-in error handler which writes the error status to context.Writer
 // users of the mux should override this with their own handler.
 func errHandler(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
 
 	// Set the headers
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(status)
 
 	// Write a simple error message page - omit error details for security reasons
-	html := fmt.Sprintf("<h1>500 Internal Error</h1>")
+	html := fmt.Sprintf("<h1>%d %s</h1>", status, http.StatusText(status))
 	io.WriteString(w, html)
 }
@@ -0,0 +1,66 @@
+package mux
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headWriter discards the body of responses to HEAD requests, and delays
+// the header until the handler returns, so that Content-Length may be set
+// from the length of the body the handler would have sent for GET.
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+	sent   bool
+}
+
+// WriteHeader records the status, informational responses are sent immediately
+func (w *headWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write discards b, recording its length and sniffing the content type if unset
+func (w *headWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.length == 0 && len(b) > 0 && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", http.DetectContentType(b))
+	}
+	w.length += len(b)
+	return len(b), nil
+}
+
+// Flush sends the header without a length, as the handler is streaming
+func (w *headWriter) Flush() {
+	w.send(false)
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// finish sends the header with the length of the discarded body if unset
+func (w *headWriter) finish() {
+	w.send(true)
+}
+
+// send writes the header once, setting Content-Length if final and possible
+func (w *headWriter) send(final bool) {
+	if w.sent {
+		return
+	}
+	w.sent = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	bodyAllowed := w.status != http.StatusNoContent && w.status != http.StatusNotModified
+	if final && bodyAllowed && w.length > 0 && w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.length))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,52 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHead tests HEAD requests are served by GET handlers without a body.
+func TestHead(t *testing.T) {
+	m := New()
+	m.Get("/page", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("<html><body>hello</body></html>"))
+		return nil
+	})
+	m.Get("/empty", func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	m.Get("/sized", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("partial"))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/page", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("head: wrong response got:%d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Length") != "31" || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("head: wrong headers got:%v", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/empty", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Content-Length") != "" {
+		t.Errorf("head: wrong response for empty got:%d %v", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/sized", nil))
+	if w.Header().Get("Content-Length") != "100" || w.Body.Len() != 0 {
+		t.Errorf("head: content length overwritten got:%v", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/page", nil))
+	if w.Body.Len() != 31 {
+		t.Errorf("head: wrong body for get got:%q", w.Body.String())
+	}
+}
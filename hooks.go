@@ -0,0 +1,64 @@
+package mux
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/middleware/wrap"
+)
+
+// RequestHook is called before each request is handled
+type RequestHook func(r *http.Request)
+
+// ResponseHook is called after each request is handled, with the status sent,
+// the time taken and the error passed to the ErrorHandler (if any).
+type ResponseHook func(r *http.Request, status int, duration time.Duration, err error)
+
+// OnRequest adds a hook called before every request, before the middleware chain.
+// Hooks run for all requests, including those served by the FileHandler
+// and those rejected by the mux, so they are suitable for observability.
+// Hooks should be added before serving.
+func (m *Mux) OnRequest(hook RequestHook) {
+	m.requestHooks = append(m.requestHooks, hook)
+}
+
+// OnResponse adds a hook called after every request, after the middleware chain.
+// Hooks should be added before serving.
+func (m *Mux) OnResponse(hook ResponseHook) {
+	m.responseHooks = append(m.responseHooks, hook)
+}
+
+// serveWithHooks serves the request, calling the request and response hooks
+func (m *Mux) serveWithHooks(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	for _, hook := range m.requestHooks {
+		hook(r)
+	}
+
+	if len(m.responseHooks) == 0 {
+		m.serve(w, r)
+		return
+	}
+
+	rec := wrap.NewRecorder(w)
+	m.serve(wrap.Wrap(w, rec), r)
+
+	duration := time.Since(start)
+	err, _ := Value[error](r, KeyError)
+	for _, hook := range m.responseHooks {
+		hook(r, rec.Status, duration, err)
+	}
+}
+
+// handleError records err on the request under KeyError and in the request log fields
+// (for access loggers outside the mux), and passes it to the ErrorHandler
+func (m *Mux) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if t := m.timing(r); t != nil {
+		start := time.Now()
+		defer func() { t.Error += time.Since(start) }()
+	}
+	SetValue(r, KeyError, err)
+	log.SetField(r, log.FieldError, err)
+	m.ErrorHandler(w, r, err)
+}
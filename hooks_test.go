@@ -0,0 +1,66 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHooks tests request and response hooks are called for every request.
+func TestHooks(t *testing.T) {
+	m := New()
+	m.Get("/", handler)
+	m.Get("/error", func(w http.ResponseWriter, r *http.Request) error {
+		return NewStatusError(http.StatusForbidden, errors.New("forbidden"))
+	})
+
+	var requests []string
+	var statuses []int
+	var errs []error
+	m.OnRequest(func(r *http.Request) {
+		requests = append(requests, r.URL.Path)
+	})
+	m.OnResponse(func(r *http.Request, status int, duration time.Duration, err error) {
+		statuses = append(statuses, status)
+		errs = append(errs, err)
+		if duration <= 0 {
+			t.Errorf("hooks: no duration recorded")
+		}
+	})
+
+	for _, p := range []string{"/", "/error", "/missing"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	if len(requests) != 3 || requests[2] != "/missing" {
+		t.Errorf("hooks: request hooks not called got:%v", requests)
+	}
+	if len(statuses) != 3 || statuses[0] != http.StatusOK || statuses[1] != http.StatusForbidden || statuses[2] != http.StatusNotFound {
+		t.Errorf("hooks: wrong statuses got:%v", statuses)
+	}
+	if errs[0] != nil || errs[1] == nil || errs[1].Error() != "forbidden" {
+		t.Errorf("hooks: wrong errors got:%v", errs)
+	}
+}
+
+// TestHooksEarlyHints tests response hooks receive the final status, not 103 Early Hints.
+func TestHooksEarlyHints(t *testing.T) {
+	m := New()
+	m.EarlyHints = true
+	m.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}).Preload("/assets/app.css")
+
+	status := 0
+	m.OnResponse(func(r *http.Request, s int, duration time.Duration, err error) {
+		status = s
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if status != http.StatusNotFound {
+		t.Errorf("hooks: wrong status with early hints got:%d want:%d", status, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,96 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrLimited is returned (as a 503 StatusError) to the ErrorHandler
+// when a request is rejected because too many requests are in flight.
+var ErrLimited = errors.New("mux: too many requests in flight")
+
+// limiter caps the number of requests in flight
+type limiter struct {
+	slots chan struct{}
+}
+
+// newLimiter returns a limiter allowing max requests in flight, or nil if max is not positive.
+func newLimiter(max int) *limiter {
+	if max <= 0 {
+		return nil
+	}
+	return &limiter{slots: make(chan struct{}, max)}
+}
+
+// acquire waits up to wait for a slot and returns true, or false if none
+// became available within the wait time or the request was cancelled.
+func (l *limiter) acquire(r *http.Request, wait time.Duration) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if wait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// release frees a slot
+func (l *limiter) release() {
+	<-l.slots
+}
+
+// LimitConcurrency caps the number of requests in flight on the mux at max,
+// requests wait up to wait for a slot before being rejected with
+// 503 Service Unavailable. A max of 0 or less removes the limit.
+// This should be called before serving.
+func (m *Mux) LimitConcurrency(max int, wait time.Duration) {
+	m.limitWait = wait
+	m.limiter = newLimiter(max)
+}
+
+// LimitRoute caps the number of requests in flight for route at max, so that
+// a slow route cannot exhaust resources for others. Requests wait for the
+// duration set by LimitConcurrency (or not at all if unset), whether it
+// is called before or after LimitRoute. A max of 0 or less removes the limit.
+// This should be called before serving.
+func (m *Mux) LimitRoute(route Route, max int) Route {
+	l := newLimiter(max)
+	if l == nil {
+		delete(m.routeLimiters, route)
+		return route
+	}
+	if m.routeLimiters == nil {
+		m.routeLimiters = make(map[Route]*limiter)
+	}
+	m.routeLimiters[route] = l
+	return route
+}
+
+// rejectLimited sends the request to the ErrorHandler with a 503 error
+func (m *Mux) rejectLimited(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter(m.limitWait)))
+	m.handleError(w, r, NewStatusError(http.StatusServiceUnavailable, ErrLimited))
+}
+
+// retryAfter returns a suitable Retry-After value in seconds for the wait given
+func retryAfter(wait time.Duration) int {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
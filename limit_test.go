@@ -0,0 +1,130 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLimitRoute tests requests over the route limit are rejected with 503.
+func TestLimitRoute(t *testing.T) {
+	m := New()
+	m.LimitConcurrency(10, 10*time.Millisecond)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	m.LimitRoute(m.Get("/slow", func(w http.ResponseWriter, r *http.Request) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}), 1)
+	m.Get("/fast", handler)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+
+	// A second slow request is rejected after waiting
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("limit: request over limit not rejected got:%d %s", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Other routes are unaffected
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("limit: other route rejected got:%d", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+// TestLimitConcurrency tests requests over the global limit are rejected with 503.
+func TestLimitConcurrency(t *testing.T) {
+	m := New()
+	m.LimitConcurrency(1, 0)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	m.Get("/slow", func(w http.ResponseWriter, r *http.Request) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+	m.Get("/fast", handler)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("limit: request over global limit not rejected got:%d", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("limit: request rejected after slot released got:%d", w.Code)
+	}
+}
+
+// TestLimitInvalid tests limits of 0 or less remove the limit, and route limits
+// use the wait set by LimitConcurrency whenever it is called.
+func TestLimitInvalid(t *testing.T) {
+	m := New()
+	m.LimitConcurrency(-1, 0)
+	m.LimitRoute(m.Get("/zero", handler), 0)
+	m.LimitRoute(m.Get("/negative", handler), -1)
+
+	for _, p := range []string{"/zero", "/negative"} {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("limit: request rejected for %s got:%d", p, w.Code)
+		}
+	}
+
+	// The wait is read when serving, so a slot freed while waiting is used
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	route := m.LimitRoute(m.Get("/slow", func(w http.ResponseWriter, r *http.Request) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}), 1)
+	m.LimitConcurrency(0, time.Second)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusOK || m.routeLimiters[route] == nil {
+		t.Errorf("limit: request did not wait for slot got:%d", w.Code)
+	}
+	wg.Wait()
+}
@@ -1,4 +1,5 @@
-// Package influx sends values to an influxdb database
+// Package influx sends values to an influxdb 1.x database using the influxdb client.
+// For influxdb 2.x buckets use package log/adapters/influxdb.
 package influx
 
 import (
@@ -0,0 +1,295 @@
+// Package influxdb sends values to an influxdb 2.x bucket using batched line protocol writes
+// over the http api, without client dependencies. For influxdb 1.x databases use
+// package log/adapters/influx, which writes with the influxdb 1.x client.
+package influxdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// Usage
+// l,err := influxdb.New(influxdb.Config{URL:"http://localhost:8086",Org:"org",Bucket:"stats",Token:"token"})
+// log.AddValuesLog(l)
+// ...
+// log.Values(map[string]interface{}{log.SeriesName:"requests","key":value})
+
+// Config represents the config for an influxdb.Logger instance
+type Config struct {
+	URL           string        // The influxdb server url e.g. http://localhost:8086
+	Org           string        // The influxdb organisation name
+	Bucket        string        // The influxdb bucket name
+	Token         string        // The influxdb api token
+	BatchSize     int           // The number of points to buffer before writing
+	FlushInterval time.Duration // The maximum time points are buffered before writing
+	WriteTimeout  time.Duration // Timeout for influxdb writes
+}
+
+// New returns a new influxdb logger, which flushes points
+// every FlushInterval or when BatchSize points are buffered.
+func New(config Config) (*Logger, error) {
+	if config.URL == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("stats: influxdb url and bucket required")
+	}
+
+	// Set defaults if none set
+	if config.BatchSize == 0 {
+		config.BatchSize = 1000
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = 10 * time.Second
+	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = 30 * time.Second
+	}
+
+	// Build the write url once on startup
+	query := url.Values{}
+	query.Set("org", config.Org)
+	query.Set("bucket", config.Bucket)
+	query.Set("precision", "ms")
+	writeURL := strings.TrimSuffix(config.URL, "/") + "/api/v2/write?" + query.Encode()
+
+	l := &Logger{
+		config:    config,
+		writeURL:  writeURL,
+		client:    &http.Client{Timeout: config.WriteTimeout},
+		errLogger: StdErrLogger{},
+		done:      make(chan struct{}),
+	}
+
+	go l.flushLoop()
+
+	return l, nil
+}
+
+// Logger logs values to a specified influxdb bucket
+type Logger struct {
+	// config stores the configuration for connections
+	config Config
+	// writeURL is the url for line protocol writes
+	writeURL string
+	// client is used for connections to the database
+	client *http.Client
+
+	errLogger log.PrintLogger
+
+	// mu protects the buffer of points awaiting a write
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	points int
+
+	done chan struct{}
+	once sync.Once
+}
+
+// Values adds a single set of values to the batch of points to write
+func (l *Logger) Values(values map[string]interface{}) {
+	l.ValuesBatch([]map[string]interface{}{values})
+}
+
+// ValuesBatch adds multiple sets of values to the batch of points to write,
+// the batch is written if it has reached BatchSize.
+func (l *Logger) ValuesBatch(valuesArray []map[string]interface{}) {
+	l.mu.Lock()
+	for _, values := range valuesArray {
+		err := WriteLine(&l.buf, values)
+		if err != nil {
+			l.errLogger.Printf("log values: error creating point:%s", err)
+			continue
+		}
+		l.points++
+	}
+	full := l.points >= l.config.BatchSize
+	l.mu.Unlock()
+
+	if full {
+		// Always perform requests in a goroutine to avoid blocking caller
+		go l.Flush()
+	}
+}
+
+// Flush writes any buffered points to influxdb
+func (l *Logger) Flush() {
+	l.mu.Lock()
+	if l.points == 0 {
+		l.mu.Unlock()
+		return
+	}
+	body := make([]byte, l.buf.Len())
+	copy(body, l.buf.Bytes())
+	l.buf.Reset()
+	l.points = 0
+	l.mu.Unlock()
+
+	err := l.write(body)
+	if err != nil {
+		l.errLogger.Printf("log values: error writing batch:%s", err)
+	}
+}
+
+// Close stops the flush loop and writes any buffered points
+func (l *Logger) Close() {
+	l.once.Do(func() {
+		close(l.done)
+		l.Flush()
+	})
+}
+
+// SetErrorLogger sets the error logger for this influxdb.Logger
+func (l *Logger) SetErrorLogger(errLogger log.PrintLogger) {
+	l.errLogger = errLogger
+}
+
+// flushLoop flushes points every FlushInterval until the logger is closed.
+func (l *Logger) flushLoop() {
+	ticker := time.NewTicker(l.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.Flush()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// write posts the line protocol body to the influxdb write api
+func (l *Logger) write(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, l.writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if l.config.Token != "" {
+		req.Header.Set("Authorization", "Token "+l.config.Token)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+// WriteLine writes values as a single point in line protocol to buf.
+// The measurement is read from log.SeriesName (default data),
+// the time from log.KeyNameTime (default now) and tags from keys with log.TagPrefix.
+// The values map is not modified.
+func WriteLine(buf *bytes.Buffer, values map[string]interface{}) error {
+	measurement := "data"
+	t := time.Now().UTC()
+	var tags, fields []string
+
+	for k, v := range values {
+		switch {
+		case k == log.SeriesName:
+			s, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("series name is not a string")
+			}
+			measurement = s
+		case k == log.KeyNameTime:
+			vt, ok := v.(time.Time)
+			if !ok {
+				return fmt.Errorf("time value is not a time")
+			}
+			t = vt
+		case k == log.KeyNameSampleRate:
+			fields = append(fields, "sample_rate="+formatField(v))
+		case strings.HasPrefix(k, log.TagPrefix):
+			s, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("tag value is not a string")
+			}
+			tags = append(tags, escape(strings.TrimPrefix(k, log.TagPrefix), tagEscaper)+"="+escape(s, tagEscaper))
+		default:
+			fields = append(fields, escape(k, tagEscaper)+"="+formatField(v))
+		}
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("no fields for %s", measurement)
+	}
+
+	// Sort tags and fields for best performance on the server
+	sort.Strings(tags)
+	sort.Strings(fields)
+
+	buf.WriteString(escape(measurement, measurementEscaper))
+	for _, tag := range tags {
+		buf.WriteByte(',')
+		buf.WriteString(tag)
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(strings.Join(fields, ","))
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10))
+	buf.WriteByte('\n')
+	return nil
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`, "\n", `\n`)
+)
+
+// escape escapes s with the replacer given
+func escape(s string, r *strings.Replacer) string {
+	return r.Replace(s)
+}
+
+// formatField formats a field value according to its type in line protocol
+func formatField(v interface{}) string {
+	switch f := v.(type) {
+	case int:
+		return strconv.FormatInt(int64(f), 10) + "i"
+	case int32:
+		return strconv.FormatInt(int64(f), 10) + "i"
+	case int64:
+		return strconv.FormatInt(f, 10) + "i"
+	case uint:
+		return strconv.FormatUint(uint64(f), 10) + "u"
+	case uint64:
+		return strconv.FormatUint(f, 10) + "u"
+	case time.Duration:
+		return strconv.FormatInt(int64(f), 10) + "i"
+	case float32:
+		return strconv.FormatFloat(float64(f), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(f)
+	case string:
+		return `"` + stringEscaper.Replace(f) + `"`
+	default:
+		return `"` + stringEscaper.Replace(fmt.Sprint(f)) + `"`
+	}
+}
+
+// StdErrLogger prints to stdout using fmt.Printf
+// and is used as the default error logger for stats errors
+type StdErrLogger struct{}
+
+// Printf prints to stdout using fmt.Printf
+func (l StdErrLogger) Printf(f string, args ...interface{}) {
+	fmt.Printf(f, args...)
+}
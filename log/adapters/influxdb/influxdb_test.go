@@ -0,0 +1,46 @@
+package influxdb
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// TestClose tests buffered points are written on Close, and Close may be called twice.
+func TestClose(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	l, err := New(Config{URL: s.URL, Bucket: "stats"})
+	if err != nil {
+		t.Fatalf("influxdb: error creating logger %s", err)
+	}
+	l.Values(map[string]interface{}{
+		log.SeriesName:          "requests",
+		log.KeyNameTime:         time.UnixMilli(1500000000000),
+		log.TagPrefix + "route": "/users/{id}",
+		"duration":              12,
+	})
+	l.Close()
+	l.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := "requests,route=/users/{id} duration=12i 1500000000000\n"
+	if len(bodies) != 1 || bodies[0] != want {
+		t.Errorf("influxdb: wrong writes got:%q want:%q", bodies, want)
+	}
+}
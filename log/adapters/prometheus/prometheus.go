@@ -0,0 +1,239 @@
+// Package prometheus converts values into prometheus metrics,
+// and exposes them as a collector to be scraped by a prometheus server.
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Usage
+// l := prometheus.New(prometheus.Config{Namespace:"myapp",LabelKeys:[]string{"method","code"}})
+// log.AddValuesLog(l)
+// m.AddHandler("/metrics", l.Handler().ServeHTTP)
+// ...
+// log.Values(map[string]interface{}{log.SeriesName:"requests","method":"GET","code":200,"duration":d.Nanoseconds()})
+
+// Config represents the config for a prometheus.Logger instance
+type Config struct {
+	Namespace  string    // Namespace prefix for all metric names
+	LabelKeys  []string  // Keys used as labels, read from values or tags
+	Counters   []string  // Keys whose values are added to a counter
+	Histograms []string  // Keys whose values are observed in a histogram
+	Durations  []string  // Keys with values in nanoseconds, reported in seconds
+	Buckets    []float64 // Histogram buckets, defaults to prometheus.DefBuckets
+}
+
+// New returns a new prometheus logger.
+// For every series a counter <namespace>_<series>_total is incremented
+// for each set of values, with labels set from LabelKeys.
+func New(config Config) *Logger {
+	// Default to observing request durations as logged by logrequest
+	if config.Histograms == nil {
+		config.Histograms = []string{"duration"}
+	}
+	if config.Durations == nil {
+		config.Durations = []string{"duration"}
+	}
+	if config.Buckets == nil {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	durations := make(map[string]bool, len(config.Durations))
+	for _, k := range config.Durations {
+		durations[k] = true
+	}
+
+	l := &Logger{
+		config:     config,
+		durations:  durations,
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+	return l
+}
+
+// Logger records values as prometheus metrics,
+// it conforms to both log.ValuesLogger and prometheus.Collector.
+type Logger struct {
+	config    Config
+	durations map[string]bool
+
+	// mu protects metrics which are created lazily for each series
+	mu         sync.RWMutex
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// Values records a single set of values
+func (l *Logger) Values(values map[string]interface{}) {
+	series := "data"
+	if s, ok := values[log.SeriesName].(string); ok {
+		series = s
+	}
+	labels := l.labelValues(values)
+
+	// Scale counts for sampled values to estimate the true total
+	weight := 1.0
+	if rate, ok := values[log.KeyNameSampleRate].(float64); ok && rate > 0 {
+		weight = 1 / rate
+	}
+
+	l.counter(l.name(series, "total")).WithLabelValues(labels...).Add(weight)
+
+	for _, k := range l.config.Counters {
+		f, ok := toFloat(values[k])
+		if ok && f >= 0 {
+			l.counter(l.name(series, k, "total")).WithLabelValues(labels...).Add(f * weight)
+		}
+	}
+
+	for _, k := range l.config.Histograms {
+		f, ok := toFloat(values[k])
+		if !ok {
+			continue
+		}
+		name := l.name(series, k)
+		if l.durations[k] {
+			f = f / float64(time.Second)
+			name = l.name(series, k, "seconds")
+		}
+		l.histogram(name).WithLabelValues(labels...).Observe(f)
+	}
+}
+
+// ValuesBatch records multiple sets of values
+func (l *Logger) ValuesBatch(valuesArray []map[string]interface{}) {
+	for _, values := range valuesArray {
+		l.Values(values)
+	}
+}
+
+// Describe conforms to prometheus.Collector, as metrics are created lazily
+// for each series no descriptions are sent, so this is an unchecked collector.
+func (l *Logger) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect conforms to prometheus.Collector and sends all metrics to ch
+func (l *Logger) Collect(ch chan<- prometheus.Metric) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, c := range l.counters {
+		c.Collect(ch)
+	}
+	for _, h := range l.histograms {
+		h.Collect(ch)
+	}
+}
+
+// Handler returns a handler serving the metrics in this logger only,
+// to serve all metrics register the logger with prometheus.MustRegister instead.
+func (l *Logger) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(l)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// counter returns the counter with this name, creating it if necessary
+func (l *Logger) counter(name string) *prometheus.CounterVec {
+	l.mu.RLock()
+	c, ok := l.counters[name]
+	l.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, ok = l.counters[name]
+	if !ok {
+		c = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: name,
+			Help: fmt.Sprintf("Counter %s from log values", name),
+		}, l.config.LabelKeys)
+		l.counters[name] = c
+	}
+	return c
+}
+
+// histogram returns the histogram with this name, creating it if necessary
+func (l *Logger) histogram(name string) *prometheus.HistogramVec {
+	l.mu.RLock()
+	h, ok := l.histograms[name]
+	l.mu.RUnlock()
+	if ok {
+		return h
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok = l.histograms[name]
+	if !ok {
+		h = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    name,
+			Help:    fmt.Sprintf("Histogram %s from log values", name),
+			Buckets: l.config.Buckets,
+		}, l.config.LabelKeys)
+		l.histograms[name] = h
+	}
+	return h
+}
+
+// labelValues returns the label values in LabelKeys order,
+// values are read from the key or the key with log.TagPrefix.
+func (l *Logger) labelValues(values map[string]interface{}) []string {
+	labels := make([]string, len(l.config.LabelKeys))
+	for i, k := range l.config.LabelKeys {
+		v, ok := values[k]
+		if !ok {
+			v, ok = values[log.TagPrefix+k]
+		}
+		if ok {
+			labels[i] = fmt.Sprint(v)
+		}
+	}
+	return labels
+}
+
+// name returns a valid metric name from the parts given
+func (l *Logger) name(parts ...string) string {
+	if l.config.Namespace != "" {
+		parts = append([]string{l.config.Namespace}, parts...)
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, strings.Join(parts, "_"))
+}
+
+// toFloat converts numeric values to a float
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case time.Duration:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,72 @@
+package prometheus
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestValues tests values are exposed as counters and histograms with labels.
+func TestValues(t *testing.T) {
+	l := New(Config{Namespace: "myapp", LabelKeys: []string{"method", "route"}, Counters: []string{"bytes"}})
+	l.Values(map[string]interface{}{
+		log.SeriesName:          "requests",
+		log.TagPrefix + "route": "/users/{id}",
+		"method":                "GET",
+		"duration":              250 * time.Millisecond,
+		"bytes":                 100,
+	})
+	l.Values(map[string]interface{}{
+		log.SeriesName:          "requests",
+		log.TagPrefix + "route": "/users/{id}",
+		"method":                "GET",
+		"duration":              int64(500 * time.Millisecond),
+		"bytes":                 200,
+	})
+
+	w := httptest.NewRecorder()
+	l.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+
+	for _, line := range []string{
+		"# TYPE myapp_requests_total counter",
+		`myapp_requests_total{method="GET",route="/users/{id}"} 2`,
+		`myapp_requests_bytes_total{method="GET",route="/users/{id}"} 300`,
+		"# TYPE myapp_requests_duration_seconds histogram",
+		`myapp_requests_duration_seconds_bucket{method="GET",route="/users/{id}",le="0.25"} 1`,
+		`myapp_requests_duration_seconds_bucket{method="GET",route="/users/{id}",le="0.5"} 2`,
+		`myapp_requests_duration_seconds_sum{method="GET",route="/users/{id}"} 0.75`,
+		`myapp_requests_duration_seconds_count{method="GET",route="/users/{id}"} 2`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("prometheus: missing line %s got:\n%s", line, body)
+		}
+	}
+}
+
+// TestValuesSampled tests counts for sampled values are scaled by the sample rate.
+func TestValuesSampled(t *testing.T) {
+	l := New(Config{Counters: []string{"bytes"}})
+	l.Values(map[string]interface{}{log.KeyNameSampleRate: 0.25, "bytes": 10})
+
+	if got := testutil.ToFloat64(l.counter("data_total")); got != 4 {
+		t.Errorf("prometheus: wrong sampled count got:%g want:4", got)
+	}
+	if got := testutil.ToFloat64(l.counter("data_bytes_total")); got != 40 {
+		t.Errorf("prometheus: wrong sampled counter got:%g want:40", got)
+	}
+}
+
+// TestName tests metric names are sanitized.
+func TestName(t *testing.T) {
+	l := New(Config{Namespace: "my-app"})
+	if got := l.name("http.requests", "duration ms"); got != "my_app_http_requests_duration_ms" {
+		t.Errorf("prometheus: wrong name got:%s", got)
+	}
+}
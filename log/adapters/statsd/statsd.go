@@ -0,0 +1,245 @@
+// Package statsd sends values to a statsd server over udp,
+// with optional DogStatsD tags for Datadog and compatible agents.
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// Usage
+// l,err := statsd.New(statsd.Config{Addr:"localhost:8125",DogStatsD:true,TagKeys:[]string{"method","code"}})
+// log.AddValuesLog(l)
+// ...
+// log.Values(map[string]interface{}{log.SeriesName:"requests","duration":d.Nanoseconds()})
+
+// MaxPacketSize is the maximum size of udp packets sent,
+// chosen to avoid fragmentation on most networks.
+const MaxPacketSize = 1432
+
+// Config represents the config for a statsd.Logger instance
+type Config struct {
+	Addr      string   // The statsd host:port
+	Prefix    string   // Prefix for all metric names e.g. myapp.
+	DogStatsD bool     // Send tags using the DogStatsD extension
+	Counters  []string // Keys sent as counters
+	Timers    []string // Keys sent as timers, values are durations in nanoseconds
+	TagKeys   []string // Keys sent as tags rather than metrics (DogStatsD only)
+}
+
+// New returns a new statsd logger.
+// Each set of values increments a counter named for the series,
+// numeric values are sent as timers or counters if configured,
+// otherwise as gauges. Non-numeric values are ignored unless used as tags.
+func New(config Config) (*Logger, error) {
+	// Default to timing request durations as logged by logrequest
+	if config.Timers == nil {
+		config.Timers = []string{"duration"}
+	}
+
+	conn, err := net.Dial("udp", config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("stats: error creating connection:%s", err)
+	}
+
+	l := &Logger{
+		config:    config,
+		conn:      conn,
+		counters:  keySet(config.Counters),
+		timers:    keySet(config.Timers),
+		tags:      keySet(config.TagKeys),
+		errLogger: StdErrLogger{},
+	}
+	return l, nil
+}
+
+// Logger logs values to a statsd server
+type Logger struct {
+	// config stores the configuration for connections
+	config Config
+	// conn is the udp connection to the server
+	conn net.Conn
+
+	counters map[string]bool
+	timers   map[string]bool
+	tags     map[string]bool
+
+	errLogger log.PrintLogger
+}
+
+// Values sends a single set of values to statsd
+func (l *Logger) Values(values map[string]interface{}) {
+	l.ValuesBatch([]map[string]interface{}{values})
+}
+
+// ValuesBatch sends multiple sets of values to statsd,
+// packing metrics into as few packets as possible.
+func (l *Logger) ValuesBatch(valuesArray []map[string]interface{}) {
+	var packet bytes.Buffer
+	for _, values := range valuesArray {
+		for _, line := range l.Lines(values) {
+			if packet.Len() > 0 && packet.Len()+len(line)+1 > MaxPacketSize {
+				l.write(packet.Bytes())
+				packet.Reset()
+			}
+			if packet.Len() > 0 {
+				packet.WriteByte('\n')
+			}
+			packet.WriteString(line)
+		}
+	}
+	if packet.Len() > 0 {
+		l.write(packet.Bytes())
+	}
+}
+
+// Lines returns the statsd lines for a set of values.
+func (l *Logger) Lines(values map[string]interface{}) []string {
+	series := "data"
+	if s, ok := values[log.SeriesName].(string); ok {
+		series = s
+	}
+	name := l.config.Prefix + sanitize(series)
+	suffix := l.tagSuffix(values)
+
+	// Sampled values are sent with the sample rate so that counts are scaled
+	rate := ""
+	if r, ok := values[log.KeyNameSampleRate].(float64); ok && r < 1 {
+		rate = "|@" + strconv.FormatFloat(r, 'f', -1, 64)
+	}
+
+	lines := []string{name + ".count:1|c" + rate + suffix}
+
+	for k, v := range values {
+		if k == log.SeriesName || k == log.KeyNameTime || k == log.KeyNameSampleRate || strings.HasPrefix(k, log.TagPrefix) || l.tags[k] {
+			continue
+		}
+		f, ok := toFloat(v)
+		if !ok {
+			continue
+		}
+
+		metric := name + "." + sanitize(k) + ":"
+		switch {
+		case l.timers[k]:
+			// Durations are logged in nanoseconds, statsd expects milliseconds
+			metric += strconv.FormatFloat(f/float64(time.Millisecond), 'f', -1, 64) + "|ms" + rate
+		case l.counters[k]:
+			metric += strconv.FormatFloat(f, 'f', -1, 64) + "|c" + rate
+		default:
+			metric += strconv.FormatFloat(f, 'f', -1, 64) + "|g"
+		}
+		lines = append(lines, metric+suffix)
+	}
+
+	return lines
+}
+
+// SetErrorLogger sets the error logger for this statsd.Logger
+func (l *Logger) SetErrorLogger(errLogger log.PrintLogger) {
+	l.errLogger = errLogger
+}
+
+// Close closes the udp connection
+func (l *Logger) Close() error {
+	return l.conn.Close()
+}
+
+// write sends a packet, udp writes do not block on the server
+func (l *Logger) write(packet []byte) {
+	_, err := l.conn.Write(packet)
+	if err != nil {
+		l.errLogger.Printf("log values: error writing statsd packet:%s", err)
+	}
+}
+
+// tagSuffix returns the DogStatsD tag suffix for these values (if any)
+func (l *Logger) tagSuffix(values map[string]interface{}) string {
+	if !l.config.DogStatsD {
+		return ""
+	}
+
+	var tags []string
+	for k, v := range values {
+		switch {
+		case strings.HasPrefix(k, log.TagPrefix):
+			tags = append(tags, sanitizeTag(strings.TrimPrefix(k, log.TagPrefix))+":"+sanitizeTag(fmt.Sprint(v)))
+		case l.tags[k]:
+			tags = append(tags, sanitizeTag(k)+":"+sanitizeTag(fmt.Sprint(v)))
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+
+	sort.Strings(tags)
+	return "|#" + strings.Join(tags, ",")
+}
+
+// toFloat converts numeric values (and bools) to a float
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case time.Duration:
+		return float64(n), true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// keySet returns a set of the keys given
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+var (
+	nameReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", " ", "_", "\n", "_")
+	tagReplacer  = strings.NewReplacer(",", "_", "|", "_", "#", "_", " ", "_", "\n", "_")
+)
+
+// sanitize removes characters reserved by the statsd protocol from metric names
+func sanitize(s string) string {
+	return nameReplacer.Replace(s)
+}
+
+// sanitizeTag removes characters reserved by the DogStatsD protocol from tags
+func sanitizeTag(s string) string {
+	return tagReplacer.Replace(s)
+}
+
+// StdErrLogger prints to stdout using fmt.Printf
+// and is used as the default error logger for stats errors
+type StdErrLogger struct{}
+
+// Printf prints to stdout using fmt.Printf
+func (l StdErrLogger) Printf(f string, args ...interface{}) {
+	fmt.Printf(f, args...)
+}
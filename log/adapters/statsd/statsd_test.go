@@ -0,0 +1,120 @@
+package statsd
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// TestLines tests values are formatted as counters, timers and gauges with DogStatsD tags.
+func TestLines(t *testing.T) {
+	l := &Logger{
+		config:   Config{Prefix: "myapp.", DogStatsD: true},
+		counters: keySet([]string{"bytes"}),
+		timers:   keySet([]string{"duration"}),
+		tags:     keySet([]string{"method"}),
+	}
+
+	tests := []struct {
+		values map[string]interface{}
+		want   []string
+	}{
+		{
+			map[string]interface{}{
+				log.SeriesName:          "requests",
+				log.KeyNameTime:         time.Now(),
+				log.TagPrefix + "route": "/users/{id}",
+				"method":                "GET",
+				"duration":              1500 * time.Microsecond,
+				"bytes":                 512,
+				"ok":                    true,
+				"open connections":      int64(3),
+				"name":                  "ignored",
+			},
+			[]string{
+				"myapp.requests.bytes:512|c|#method:GET,route:/users/{id}",
+				"myapp.requests.count:1|c|#method:GET,route:/users/{id}",
+				"myapp.requests.duration:1.5|ms|#method:GET,route:/users/{id}",
+				"myapp.requests.ok:1|g|#method:GET,route:/users/{id}",
+				"myapp.requests.open_connections:3|g|#method:GET,route:/users/{id}",
+			},
+		},
+		{
+			map[string]interface{}{
+				log.KeyNameSampleRate: 0.25,
+				"bytes":               10,
+				"duration":            int64(time.Millisecond),
+				"load":                0.5,
+			},
+			[]string{
+				"myapp.data.bytes:10|c|@0.25",
+				"myapp.data.count:1|c|@0.25",
+				"myapp.data.duration:1|ms|@0.25",
+				"myapp.data.load:0.5|g",
+			},
+		},
+		{
+			map[string]interface{}{log.SeriesName: "a:b|c", log.TagPrefix + "host": "web 1,eu|#"},
+			[]string{"myapp.a_b_c.count:1|c|#host:web_1_eu__"},
+		},
+	}
+
+	for _, tc := range tests {
+		lines := l.Lines(tc.values)
+		sort.Strings(lines)
+		if strings.Join(lines, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("statsd: wrong lines got:%q want:%q", lines, tc.want)
+		}
+	}
+}
+
+// TestLinesNoTags tests tags are omitted unless DogStatsD is set.
+func TestLinesNoTags(t *testing.T) {
+	l := &Logger{tags: keySet([]string{"method"})}
+	lines := l.Lines(map[string]interface{}{log.SeriesName: "requests", "method": "GET", log.TagPrefix + "route": "/"})
+	if len(lines) != 1 || lines[0] != "requests.count:1|c" {
+		t.Errorf("statsd: wrong lines without tags got:%q", lines)
+	}
+}
+
+// TestValuesBatch tests lines are packed into packets no larger than MaxPacketSize.
+func TestValuesBatch(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("statsd: error listening %s", err)
+	}
+	defer conn.Close()
+
+	l, err := New(Config{Addr: conn.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("statsd: error creating logger %s", err)
+	}
+	defer l.Close()
+
+	batch := make([]map[string]interface{}, 100)
+	for i := range batch {
+		batch[i] = map[string]interface{}{log.SeriesName: "requests", "duration": int64(time.Millisecond)}
+	}
+	l.ValuesBatch(batch)
+
+	lines := 0
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for lines < 200 {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("statsd: error reading after %d lines %s", lines, err)
+		}
+		if n > MaxPacketSize {
+			t.Errorf("statsd: packet too large got:%d", n)
+		}
+		lines += len(strings.Split(string(buf[:n]), "\n"))
+	}
+	if lines != 200 {
+		t.Errorf("statsd: wrong lines got:%d want:200", lines)
+	}
+}
@@ -15,16 +15,24 @@ type Default struct {
 
 	// Writer is the output of this logger.
 	Writer io.Writer
+
+	// Level is the threshold for leveled messages sent to this logger.
+	Level Level
+}
+
+// Enabled returns true if leveled messages at l should be written by this logger.
+func (d *Default) Enabled(l Level) bool {
+	return l >= d.Level
 }
 
 // Printf prints the format to writer using args and a time prefix
+// The line is written with a single write so that lines are not split.
 func (d *Default) Printf(format string, args ...interface{}) {
+	line := fmt.Sprintf(format, args...) + "\n"
 	if d.PrefixTimeFormat != "" {
-		d.WriteString(time.Now().UTC().Format(d.PrefixTimeFormat))
+		line = time.Now().UTC().Format(d.PrefixTimeFormat) + line
 	}
-
-	d.WriteString(fmt.Sprintf(format, args...))
-	d.WriteString("\n")
+	d.WriteString(line)
 }
 
 // WriteString writes the string to the Writer.
@@ -2,6 +2,7 @@ package log
 
 import (
 	"errors"
+	"io"
 	"os"
 )
 
@@ -36,7 +37,43 @@ func NewFile(path string) (*File, error) {
 			PrefixTimeFormat: PrefixDateTime,
 			Writer:           logFile,
 		},
+		Path: path,
 	}
 
 	return f, nil
 }
+
+// NewRotatingFile creates a new file logger for the given path,
+// which rotates the file according to the rotation given.
+func NewRotatingFile(path string, rotation Rotation) (*File, error) {
+	if path == "" {
+		return nil, errors.New("log: null file path for file log")
+	}
+
+	w := &RotatingWriter{
+		Path:     path,
+		Rotation: rotation,
+	}
+	err := w.open()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		Default: Default{
+			PrefixTimeFormat: PrefixDateTime,
+			Writer:           w,
+		},
+		Path: path,
+	}
+
+	return f, nil
+}
+
+// Close closes the file (if the writer supports closing).
+func (f *File) Close() error {
+	if c, ok := f.Writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// Level defines the severity of a log message.
+type Level int32
+
+// Levels in order of increasing severity
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the name of the level in upper case.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "UNKNOWN"
+}
+
+// ParseLevel returns the level for a name like debug or WARN, defaulting to LevelInfo.
+func ParseLevel(name string) Level {
+	switch strings.ToUpper(name) {
+	case "DEBUG":
+		return LevelDebug
+	case "WARN", "WARNING":
+		return LevelWarn
+	case "ERROR":
+		return LevelError
+	}
+	return LevelInfo
+}
+
+// level is the package threshold, messages below this level are discarded.
+var level = int32(LevelInfo)
+
+// SetLevel sets the package threshold, leveled messages below this level are discarded.
+// The default level is LevelInfo. Printf is unaffected by the level.
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+// CurrentLevel returns the package threshold.
+func CurrentLevel() Level {
+	return Level(atomic.LoadInt32(&level))
+}
+
+// Enabled returns true if messages at this level would be logged,
+// use this to avoid expensive work preparing debug output.
+func Enabled(l Level) bool {
+	return l >= CurrentLevel()
+}
+
+// LevelLogger is a PrintLogger with its own threshold,
+// leveled messages are only sent to it if Enabled returns true.
+type LevelLogger interface {
+	PrintLogger
+	Enabled(Level) bool
+}
+
+// Logf prints to the printLogs if level is at or above the package threshold,
+// and the threshold of each logger. Messages are prefixed with the level name.
+func Logf(l Level, format string, args ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	format = l.String() + " " + format
+	for _, pl := range printLogs {
+		if ll, ok := pl.(LevelLogger); ok && !ll.Enabled(l) {
+			continue
+		}
+		pl.Printf(format, args...)
+	}
+}
+
+// Debugf prints a message at LevelDebug
+func Debugf(format string, args ...interface{}) {
+	Logf(LevelDebug, format, args...)
+}
+
+// Infof prints a message at LevelInfo
+func Infof(format string, args ...interface{}) {
+	Logf(LevelInfo, format, args...)
+}
+
+// Warnf prints a message at LevelWarn
+func Warnf(format string, args ...interface{}) {
+	Logf(LevelWarn, format, args...)
+}
+
+// Errorf prints a message at LevelError
+func Errorf(format string, args ...interface{}) {
+	Logf(LevelError, format, args...)
+}
+
+// WithLevel returns a LevelLogger wrapping l which only accepts
+// leveled messages at or above min, e.g. to send only errors to a file:
+// log.Add(log.WithLevel(fileLog, log.LevelError))
+func WithLevel(l PrintLogger, min Level) LevelLogger {
+	return &levelLogger{PrintLogger: l, min: min}
+}
+
+// levelLogger wraps a PrintLogger with a threshold.
+type levelLogger struct {
+	PrintLogger
+	min Level
+}
+
+// Enabled returns true if l is at or above the threshold.
+func (l *levelLogger) Enabled(lvl Level) bool {
+	return lvl >= l.min
+}
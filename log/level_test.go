@@ -0,0 +1,111 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+)
+
+// printLogger records the messages it receives
+type printLogger struct {
+	lines []string
+}
+
+func (p *printLogger) Printf(format string, args ...interface{}) {
+	p.lines = append(p.lines, fmt.Sprintf(format, args...))
+}
+
+// addPrintLogger replaces the printLogs with a printLogger until the test ends
+func addPrintLogger(t *testing.T) *printLogger {
+	logs := printLogs
+	t.Cleanup(func() { printLogs = logs })
+	l := &printLogger{}
+	printLogs = []PrintLogger{l}
+	return l
+}
+
+// logAll logs a message at each level, and one with Printf
+func logAll() {
+	Debugf("debug %d", 1)
+	Infof("info %d", 2)
+	Warnf("warn %d", 3)
+	Errorf("error %d", 4)
+	Printf("print %d", 5)
+}
+
+// setLevel sets the package threshold until the test ends
+func setLevel(t *testing.T, l Level) {
+	current := CurrentLevel()
+	t.Cleanup(func() { SetLevel(current) })
+	SetLevel(l)
+}
+
+// TestLevel tests leveled messages below the package threshold are discarded.
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  string
+	}{
+		{LevelDebug, "[DEBUG debug 1 INFO info 2 WARN warn 3 ERROR error 4 print 5]"},
+		{LevelInfo, "[INFO info 2 WARN warn 3 ERROR error 4 print 5]"},
+		{LevelWarn, "[WARN warn 3 ERROR error 4 print 5]"},
+		{LevelError, "[ERROR error 4 print 5]"},
+	}
+
+	for _, tc := range tests {
+		l := addPrintLogger(t)
+		setLevel(t, tc.level)
+		logAll()
+		if got := fmt.Sprint(l.lines); got != tc.want {
+			t.Errorf("level: wrong messages at %s got:%s want:%s", tc.level, got, tc.want)
+		}
+		for _, lvl := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+			if Enabled(lvl) != (lvl >= tc.level) {
+				t.Errorf("level: wrong Enabled(%s) at %s", lvl, tc.level)
+			}
+		}
+	}
+}
+
+// TestWithLevel tests each logger's threshold applies as well as the package threshold.
+func TestWithLevel(t *testing.T) {
+	all := addPrintLogger(t)
+	errorLog := &printLogger{}
+	debug := &printLogger{}
+	Add(WithLevel(errorLog, LevelError))
+	Add(WithLevel(debug, LevelDebug))
+	setLevel(t, LevelInfo)
+
+	logAll()
+	Logf(LevelWarn, "logf %s", "warn")
+
+	tests := []struct {
+		name   string
+		logger *printLogger
+		want   string
+	}{
+		{"package", all, "[INFO info 2 WARN warn 3 ERROR error 4 print 5 WARN logf warn]"},
+		{"error", errorLog, "[ERROR error 4 print 5]"},
+		{"debug", debug, "[INFO info 2 WARN warn 3 ERROR error 4 print 5 WARN logf warn]"},
+	}
+	for _, tc := range tests {
+		if got := fmt.Sprint(tc.logger.lines); got != tc.want {
+			t.Errorf("level: wrong messages for %s logger got:%s want:%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestParseLevel tests level names are parsed, defaulting to info.
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"debug":   LevelDebug,
+		"INFO":    LevelInfo,
+		"Warning": LevelWarn,
+		"error":   LevelError,
+		"verbose": LevelInfo,
+	}
+	for name, want := range tests {
+		if got := ParseLevel(name); got != want {
+			t.Errorf("level: wrong level for %s got:%s want:%s", name, got, want)
+		}
+	}
+}
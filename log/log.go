@@ -3,6 +3,7 @@
 package log
 
 import (
+	"sync"
 	"time"
 )
 
@@ -30,10 +31,29 @@ var (
 	printLogs []PrintLogger
 
 	// valueLogs stores the loggers called by the log.Values function below.
-	valueLogs []ValuesLogger
+	valueLogs []valuesLog
+
+	// valueLogsMu protects valueLogs, which may be modified after logging commences.
+	valueLogsMu sync.RWMutex
 )
 
-// Printf prints to the printLogs
+// valuesLog stores a ValuesLogger and the filters applied before sending it values.
+type valuesLog struct {
+	logger  ValuesLogger
+	filters []ValuesFilter
+}
+
+// accept returns true if all filters accept these values.
+func (v valuesLog) accept(values map[string]interface{}) bool {
+	for _, f := range v.filters {
+		if !f(values) {
+			return false
+		}
+	}
+	return true
+}
+
+// Printf prints to the printLogs regardless of level
 func Printf(format string, args ...interface{}) {
 	for _, l := range printLogs {
 		l.Printf(format, args...)
@@ -49,18 +69,58 @@ func Timef(start time.Time, format string, args ...interface{}) {
 	Printf(format+" in %s", args...)
 }
 
-// Values values to the valueLogs which typically emit stats to a time series database.
+// Values sends values to the valueLogs which typically emit stats to a time series database.
+// Each logger receives its own copy of values if its filters accept them.
+// If a Sampler is set, values may be dropped according to the sample rate.
 func Values(values map[string]interface{}) {
+	values = sample(values)
+	if values == nil {
+		return
+	}
+
+	valueLogsMu.RLock()
+	defer valueLogsMu.RUnlock()
 	for _, l := range valueLogs {
-		l.Values(values)
+		if l.accept(values) {
+			l.logger.Values(copyValues(values))
+		}
 	}
 }
 
 // ValuesBatch sends an array of values to the valueLogs which typically emit stats to a time series database.
+// Each logger receives copies of only those values its filters accept.
+// If a Sampler is set, values may be dropped according to the sample rate.
 func ValuesBatch(values []map[string]interface{}) {
+	sampled := make([]map[string]interface{}, 0, len(values))
+	for _, v := range values {
+		if v = sample(v); v != nil {
+			sampled = append(sampled, v)
+		}
+	}
+	values = sampled
+
+	valueLogsMu.RLock()
+	defer valueLogsMu.RUnlock()
 	for _, l := range valueLogs {
-		l.ValuesBatch(values)
+		var batch []map[string]interface{}
+		for _, v := range values {
+			if l.accept(v) {
+				batch = append(batch, copyValues(v))
+			}
+		}
+		if len(batch) > 0 {
+			l.logger.ValuesBatch(batch)
+		}
+	}
+}
+
+// copyValues returns a shallow copy of values, as some loggers modify the values they receive.
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		c[k] = v
 	}
+	return c
 }
 
 // Add adds the given logger to the list of outputs,
@@ -72,7 +132,44 @@ func Add(l PrintLogger) {
 // AddValuesLog adds the given logger to the list of ValuesLoggers,
 // it should be called before logging commences
 func AddValuesLog(l ValuesLogger) {
-	valueLogs = append(valueLogs, l)
+	AddValuesLogger(l)
+}
+
+// AddValuesLogger adds the given logger to the list of ValuesLoggers,
+// values are only sent to the logger if all the filters given accept them.
+// Each call to Values is sent to every logger registered.
+func AddValuesLogger(l ValuesLogger, filters ...ValuesFilter) {
+	valueLogsMu.Lock()
+	valueLogs = append(valueLogs, valuesLog{logger: l, filters: filters})
+	valueLogsMu.Unlock()
+}
+
+// RemoveValuesLogger removes the given logger from the list of ValuesLoggers.
+func RemoveValuesLogger(l ValuesLogger) {
+	valueLogsMu.Lock()
+	defer valueLogsMu.Unlock()
+	for i, v := range valueLogs {
+		if v.logger == l {
+			valueLogs = append(valueLogs[:i:i], valueLogs[i+1:]...)
+			return
+		}
+	}
+}
+
+// ValuesFilter returns true if values should be sent to a ValuesLogger.
+type ValuesFilter func(values map[string]interface{}) bool
+
+// SeriesFilter returns a filter accepting only values with one of these series names.
+func SeriesFilter(names ...string) ValuesFilter {
+	return func(values map[string]interface{}) bool {
+		series, _ := values[SeriesName].(string)
+		for _, n := range names {
+			if n == series {
+				return true
+			}
+		}
+		return false
+	}
 }
 
 // PrintLogger defines an interface for logging to a text log.
@@ -0,0 +1,117 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// valuesLogger records the values it receives
+type valuesLogger struct {
+	mu     sync.Mutex
+	values []map[string]interface{}
+}
+
+func (v *valuesLogger) Values(values map[string]interface{}) {
+	v.mu.Lock()
+	v.values = append(v.values, values)
+	v.mu.Unlock()
+}
+
+func (v *valuesLogger) ValuesBatch(values []map[string]interface{}) {
+	for _, vv := range values {
+		v.Values(vv)
+	}
+}
+
+// series returns the series names of the values received
+func (v *valuesLogger) series() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var names []string
+	for _, vv := range v.values {
+		names = append(names, fmt.Sprint(vv[SeriesName]))
+	}
+	return names
+}
+
+// addValuesLogger adds a valuesLogger with filters, removing it when the test ends
+func addValuesLogger(t *testing.T, filters ...ValuesFilter) *valuesLogger {
+	l := &valuesLogger{}
+	AddValuesLogger(l, filters...)
+	t.Cleanup(func() { RemoveValuesLogger(l) })
+	return l
+}
+
+// TestSeriesFilter tests loggers receive only the series their filters accept.
+func TestSeriesFilter(t *testing.T) {
+	all := addValuesLogger(t)
+	requests := addValuesLogger(t, SeriesFilter("requests"))
+	jobs := addValuesLogger(t, SeriesFilter("jobs", "queues"))
+	none := addValuesLogger(t, SeriesFilter("requests"), SeriesFilter("jobs"))
+
+	Values(map[string]interface{}{SeriesName: "requests"})
+	Values(map[string]interface{}{SeriesName: "jobs"})
+	ValuesBatch([]map[string]interface{}{
+		{SeriesName: "queues"},
+		{SeriesName: "requests"},
+		{"count": 1},
+	})
+
+	tests := []struct {
+		name   string
+		logger *valuesLogger
+		want   string
+	}{
+		{"all", all, "[requests jobs queues requests <nil>]"},
+		{"requests", requests, "[requests requests]"},
+		{"jobs", jobs, "[jobs queues]"},
+		{"none", none, "[]"},
+	}
+	for _, tc := range tests {
+		if got := fmt.Sprint(tc.logger.series()); got != tc.want {
+			t.Errorf("log: wrong series for %s logger got:%s want:%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestValuesCopied tests each logger receives its own copy of values.
+func TestValuesCopied(t *testing.T) {
+	a := addValuesLogger(t)
+	b := addValuesLogger(t)
+
+	values := map[string]interface{}{SeriesName: "requests", "code": 200}
+	Values(values)
+	ValuesBatch([]map[string]interface{}{values})
+
+	for i := range a.values {
+		a.values[i]["code"] = 500
+		AddTag(a.values[i], "host", "a")
+	}
+	for i, v := range b.values {
+		if len(v) != 2 || v["code"] != 200 {
+			t.Errorf("log: values %d shared between loggers got:%v", i, v)
+		}
+	}
+	if len(values) != 2 || values["code"] != 200 {
+		t.Errorf("log: values modified by logger got:%v", values)
+	}
+}
+
+// TestRemoveValuesLogger tests removed loggers receive no more values.
+func TestRemoveValuesLogger(t *testing.T) {
+	a := addValuesLogger(t)
+	b := addValuesLogger(t)
+
+	Values(map[string]interface{}{SeriesName: "first"})
+	RemoveValuesLogger(a)
+	Values(map[string]interface{}{SeriesName: "second"})
+	ValuesBatch([]map[string]interface{}{{SeriesName: "third"}})
+
+	if got := fmt.Sprint(a.series()); got != "[first]" {
+		t.Errorf("log: removed logger received values got:%s", got)
+	}
+	if got := fmt.Sprint(b.series()); got != "[first second third]" {
+		t.Errorf("log: remaining logger missed values got:%s", got)
+	}
+}
@@ -0,0 +1,162 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Field keys set by middleware and the mux for request loggers
+const (
+	FieldRequestID = "request_id"
+	FieldMethod    = "method"
+	FieldRoute     = "route"
+	FieldError     = "error"
+)
+
+// contextKey is used for storing values in request contexts
+type contextKey int
+
+// fieldsKey is the key for the request Fields
+const fieldsKey contextKey = 0
+
+// Fields stores key value pairs which are appended to log messages
+// from ForRequest, in the order the keys were first set.
+// Fields are safe for concurrent use.
+type Fields struct {
+	mu     sync.RWMutex
+	keys   []string
+	values map[string]interface{}
+}
+
+// Set sets the value for key.
+func (f *Fields) Set(key string, value interface{}) {
+	f.mu.Lock()
+	if _, ok := f.values[key]; !ok {
+		f.keys = append(f.keys, key)
+	}
+	f.values[key] = value
+	f.mu.Unlock()
+}
+
+// Get returns the value for key, or nil if not set.
+func (f *Fields) Get(key string) interface{} {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.values[key]
+}
+
+// String returns the fields formatted as key=value pairs.
+func (f *Fields) String() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	pairs := make([]string, len(f.keys))
+	for i, k := range f.keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, f.values[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// WithFields returns a request with a Fields store in its context,
+// if the request already has one it is returned unchanged.
+// Middleware should call this once, and pass the returned request on.
+func WithFields(r *http.Request) *http.Request {
+	if FieldsFromContext(r.Context()) != nil {
+		return r
+	}
+	f := &Fields{values: make(map[string]interface{})}
+	return r.WithContext(context.WithValue(r.Context(), fieldsKey, f))
+}
+
+// CopyFields returns a request with a copy of the request Fields (or empty Fields
+// if it has none), so that fields set on the returned request do not affect r.
+func CopyFields(r *http.Request) *http.Request {
+	f := &Fields{values: make(map[string]interface{})}
+	if existing := FieldsFromContext(r.Context()); existing != nil {
+		existing.mu.RLock()
+		f.keys = append(f.keys, existing.keys...)
+		for k, v := range existing.values {
+			f.values[k] = v
+		}
+		existing.mu.RUnlock()
+	}
+	return r.WithContext(context.WithValue(r.Context(), fieldsKey, f))
+}
+
+// FieldsFromContext returns the Fields stored in ctx, or nil if none.
+func FieldsFromContext(ctx context.Context) *Fields {
+	f, _ := ctx.Value(fieldsKey).(*Fields)
+	return f
+}
+
+// SetField sets a field on the request if it has a Fields store,
+// and does nothing otherwise.
+func SetField(r *http.Request, key string, value interface{}) {
+	if f := FieldsFromContext(r.Context()); f != nil {
+		f.Set(key, value)
+	}
+}
+
+// GetField returns the field for key on the request, or nil if not set.
+func GetField(r *http.Request, key string) interface{} {
+	if f := FieldsFromContext(r.Context()); f != nil {
+		return f.Get(key)
+	}
+	return nil
+}
+
+// ForRequest returns a logger which appends the request fields
+// (request id, method, route etc) to every message, so that messages
+// logged by handlers may be correlated with the access log.
+func ForRequest(r *http.Request) *RequestLogger {
+	return &RequestLogger{fields: FieldsFromContext(r.Context())}
+}
+
+// RequestLogger logs messages with the fields from a request.
+type RequestLogger struct {
+	fields *Fields
+}
+
+// Printf prints to the printLogs regardless of level, with fields appended.
+func (l *RequestLogger) Printf(format string, args ...interface{}) {
+	Printf(l.format(format), args...)
+}
+
+// Logf prints a message at level l with fields appended.
+func (l *RequestLogger) Logf(lvl Level, format string, args ...interface{}) {
+	Logf(lvl, l.format(format), args...)
+}
+
+// Debugf prints a message at LevelDebug with fields appended
+func (l *RequestLogger) Debugf(format string, args ...interface{}) {
+	l.Logf(LevelDebug, format, args...)
+}
+
+// Infof prints a message at LevelInfo with fields appended
+func (l *RequestLogger) Infof(format string, args ...interface{}) {
+	l.Logf(LevelInfo, format, args...)
+}
+
+// Warnf prints a message at LevelWarn with fields appended
+func (l *RequestLogger) Warnf(format string, args ...interface{}) {
+	l.Logf(LevelWarn, format, args...)
+}
+
+// Errorf prints a message at LevelError with fields appended
+func (l *RequestLogger) Errorf(format string, args ...interface{}) {
+	l.Logf(LevelError, format, args...)
+}
+
+// format appends the fields to the format, escaping any % in field values.
+func (l *RequestLogger) format(format string) string {
+	if l.fields == nil {
+		return format
+	}
+	fields := l.fields.String()
+	if fields == "" {
+		return format
+	}
+	return format + " " + strings.Replace(fields, "%", "%%", -1)
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestForRequest tests request fields are appended to messages in the order set.
+func TestForRequest(t *testing.T) {
+	l := addPrintLogger(t)
+	setLevel(t, LevelInfo)
+
+	r := WithFields(httptest.NewRequest(http.MethodGet, "/", nil))
+	SetField(r, FieldRequestID, "abc")
+	SetField(r, FieldMethod, "GET")
+	SetField(r, FieldRoute, "/pages/{id:int}")
+	SetField(r, FieldRequestID, "def")
+	SetField(r, "query", "%d%s")
+
+	rl := ForRequest(r)
+	rl.Printf("loaded %d%%", 100)
+	rl.Infof("page %s", "one")
+	rl.Debugf("hidden")
+	rl.Errorf("failed")
+
+	fields := "request_id=def method=GET route=/pages/{id:int} query=%d%s"
+	want := fmt.Sprint([]string{
+		"loaded 100% " + fields,
+		"INFO page one " + fields,
+		"ERROR failed " + fields,
+	})
+	if got := fmt.Sprint(l.lines); got != want {
+		t.Errorf("request: wrong messages got:%s want:%s", got, want)
+	}
+}
+
+// TestForRequestNoFields tests messages are unchanged for requests without fields.
+func TestForRequestNoFields(t *testing.T) {
+	l := addPrintLogger(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	SetField(r, FieldRequestID, "abc")
+	ForRequest(r).Printf("plain %s", "message")
+	ForRequest(WithFields(r)).Printf("empty %s", "fields")
+
+	if got := fmt.Sprint(l.lines); got != "[plain message empty fields]" {
+		t.Errorf("request: wrong messages got:%s", got)
+	}
+	if GetField(r, FieldRequestID) != nil {
+		t.Errorf("request: field set without a fields store")
+	}
+}
+
+// TestCopyFields tests fields set on a copy do not affect the original request.
+func TestCopyFields(t *testing.T) {
+	r := WithFields(httptest.NewRequest(http.MethodGet, "/", nil))
+	SetField(r, FieldRequestID, "abc")
+	if WithFields(r) != r {
+		t.Errorf("request: fields replaced by WithFields")
+	}
+
+	c := CopyFields(r)
+	SetField(c, FieldRoute, "/")
+	SetField(c, FieldRequestID, "def")
+
+	if got := FieldsFromContext(r.Context()).String(); got != "request_id=abc" {
+		t.Errorf("request: original fields modified got:%s", got)
+	}
+	if got := FieldsFromContext(c.Context()).String(); got != "request_id=def route=/" {
+		t.Errorf("request: wrong copied fields got:%s", got)
+	}
+}
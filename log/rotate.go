@@ -0,0 +1,231 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateTimeFormat is the time format appended to the path of rotated files,
+// if a file with that name exists a counter is appended, e.g. .20170102-150405.000-1
+const RotateTimeFormat = "20060102-150405.000"
+
+// Rotation defines when a log file is rotated and how many rotated files are retained.
+type Rotation struct {
+	// MaxSize rotates the file once it is larger than this many bytes, 0 means no limit.
+	MaxSize int64
+
+	// Interval rotates the file once it is older than this, 0 means no limit.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files to retain, 0 retains all files.
+	MaxBackups int
+
+	// MaxAge removes rotated files older than this, 0 retains all files.
+	MaxAge time.Duration
+}
+
+// RotatingWriter writes to a file at Path, rotating it by renaming
+// to Path.RotateTimeFormat when it exceeds the size or age limits set.
+// Rotated files are never overwritten.
+type RotatingWriter struct {
+	Path     string
+	Rotation Rotation
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	created time.Time
+}
+
+// Write writes b to the file, rotating it first if required.
+func (w *RotatingWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		err := w.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(b))) {
+		err := w.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately, e.g. in response to a signal.
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// Close closes the file.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// shouldRotate returns true if writing n bytes would exceed the limits.
+func (w *RotatingWriter) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.Rotation.MaxSize > 0 && w.size+n > w.Rotation.MaxSize {
+		return true
+	}
+	if w.Rotation.Interval > 0 && time.Since(w.created) > w.Rotation.Interval {
+		return true
+	}
+	return false
+}
+
+// open opens the file at Path for appending, recording its current size.
+func (w *RotatingWriter) open() error {
+	file, err := os.OpenFile(w.Path, FileFlags, FilePermissions)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = stat.Size()
+	w.created = time.Now()
+
+	// The file was created when the last file was rotated, if that is not
+	// known the interval is counted from now, as files have no creation time
+	if w.size > 0 {
+		rotated := w.rotated()
+		if len(rotated) > 0 {
+			w.created, _, _ = parseRotated(w.Path, rotated[len(rotated)-1])
+		}
+	}
+	return nil
+}
+
+// rotate renames the current file, opens a new one and removes old files.
+func (w *RotatingWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+
+	err := os.Rename(w.Path, rotatedPath(w.Path, time.Now()))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = w.open()
+	if err != nil {
+		return err
+	}
+
+	w.removeOld()
+	return nil
+}
+
+// removeOld removes rotated files beyond MaxBackups or older than MaxAge.
+func (w *RotatingWriter) removeOld() {
+	if w.Rotation.MaxBackups == 0 && w.Rotation.MaxAge == 0 {
+		return
+	}
+
+	rotated := w.rotated()
+	for i, p := range rotated {
+		remove := w.Rotation.MaxBackups > 0 && i < len(rotated)-w.Rotation.MaxBackups
+		if !remove && w.Rotation.MaxAge > 0 {
+			stat, err := os.Stat(p)
+			remove = err == nil && time.Since(stat.ModTime()) > w.Rotation.MaxAge
+		}
+		if remove {
+			os.Remove(p)
+		}
+	}
+}
+
+// rotated returns the paths of rotated files, oldest first.
+func (w *RotatingWriter) rotated() []string {
+	matches, err := filepath.Glob(w.Path + ".*")
+	if err != nil {
+		return nil
+	}
+
+	type file struct {
+		path    string
+		time    time.Time
+		counter int
+	}
+	var files []file
+	for _, m := range matches {
+		t, counter, ok := parseRotated(w.Path, m)
+		if ok {
+			files = append(files, file{path: m, time: t, counter: counter})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].time.Equal(files[j].time) {
+			return files[i].counter < files[j].counter
+		}
+		return files[i].time.Before(files[j].time)
+	})
+
+	rotated := make([]string, len(files))
+	for i, f := range files {
+		rotated[i] = f.path
+	}
+	return rotated
+}
+
+// rotatedPath returns a path for the file at path rotated at t,
+// appending a counter if required so that no existing file is overwritten.
+func rotatedPath(path string, t time.Time) string {
+	rotated := path + "." + t.UTC().Format(RotateTimeFormat)
+	p := rotated
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(p); os.IsNotExist(err) {
+			return p
+		}
+		p = rotated + "-" + strconv.Itoa(i)
+	}
+}
+
+// parseRotated returns the time and counter from the name of a rotated file.
+func parseRotated(path, rotated string) (time.Time, int, bool) {
+	suffix := strings.TrimPrefix(rotated, path+".")
+	counter := 0
+	if len(suffix) > len(RotateTimeFormat) && suffix[len(RotateTimeFormat)] == '-' {
+		n, err := strconv.Atoi(suffix[len(RotateTimeFormat)+1:])
+		if err != nil {
+			return time.Time{}, 0, false
+		}
+		suffix, counter = suffix[:len(RotateTimeFormat)], n
+	}
+	t, err := time.Parse(RotateTimeFormat, suffix)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return t, counter, true
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRotateSize tests files are rotated by size without overwriting earlier files.
+func TestRotateSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	w := &RotatingWriter{Path: path, Rotation: Rotation{MaxSize: 10}}
+	defer w.Close()
+
+	// Several rotations within a millisecond must not overwrite each other
+	for _, s := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("rotate: error writing:%s", err)
+		}
+	}
+
+	rotated := w.rotated()
+	if len(rotated) != 3 {
+		t.Fatalf("rotate: expected 3 rotated files got:%v", rotated)
+	}
+	var contents []string
+	for _, p := range append(rotated, path) {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("rotate: error reading:%s", err)
+		}
+		contents = append(contents, string(b))
+	}
+	if strings.Join(contents, "") != "first\nsecond\nthird\nfourth\n" {
+		t.Errorf("rotate: wrong contents got:%q", contents)
+	}
+}
+
+// TestRotateInterval tests files are rotated by age, counted from the last rotation.
+func TestRotateInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+
+	// An existing file written recently but created at the last rotation is rotated
+	rotatedAt := time.Now().Add(-time.Hour)
+	os.WriteFile(rotatedPath(path, rotatedAt), []byte("old\n"), 0644)
+	os.WriteFile(path, []byte("current\n"), 0644)
+
+	w := &RotatingWriter{Path: path, Rotation: Rotation{Interval: time.Minute}}
+	defer w.Close()
+	w.Write([]byte("next\n"))
+	if len(w.rotated()) != 2 {
+		t.Errorf("rotate: file created an hour ago not rotated got:%v", w.rotated())
+	}
+
+	// A new file is not rotated until the interval has passed
+	w.Write([]byte("more\n"))
+	if len(w.rotated()) != 2 {
+		t.Errorf("rotate: new file rotated early got:%v", w.rotated())
+	}
+
+	w.Rotation.Interval = 10 * time.Millisecond
+	time.Sleep(20 * time.Millisecond)
+	w.Write([]byte("last\n"))
+	if len(w.rotated()) != 3 {
+		t.Errorf("rotate: file not rotated after interval got:%v", w.rotated())
+	}
+}
+
+// TestRotateRemove tests rotated files beyond MaxBackups or older than MaxAge are removed.
+func TestRotateRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	now := time.Now()
+	for i := 5; i > 0; i-- {
+		p := rotatedPath(path, now.Add(-time.Duration(i)*time.Hour))
+		os.WriteFile(p, []byte("old\n"), 0644)
+		modified := now.Add(-time.Duration(i) * time.Hour)
+		os.Chtimes(p, modified, modified)
+	}
+	os.WriteFile(path, []byte("current\n"), 0644)
+
+	w := &RotatingWriter{Path: path, Rotation: Rotation{MaxBackups: 4}}
+	defer w.Close()
+	if err := w.Rotate(); err != nil {
+		t.Fatalf("rotate: error rotating:%s", err)
+	}
+	if len(w.rotated()) != 4 {
+		t.Errorf("rotate: MaxBackups not applied got:%v", w.rotated())
+	}
+
+	w.Rotation = Rotation{MaxAge: 150 * time.Minute}
+	w.removeOld()
+	rotated := w.rotated()
+	if len(rotated) != 3 {
+		t.Errorf("rotate: MaxAge not applied got:%v", rotated)
+	}
+	if b, _ := os.ReadFile(rotated[len(rotated)-1]); string(b) != "current\n" {
+		t.Errorf("rotate: newest file removed got:%q", b)
+	}
+}
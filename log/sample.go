@@ -0,0 +1,104 @@
+package log
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// KeyNameSampleRate specifies the key used to record the rate at which values were sampled,
+// adapters may use this to scale counts. The value is a float64 between 0 and 1.
+const KeyNameSampleRate = "stats_key_name_sample_rate"
+
+// Sampler returns the rate (between 0 and 1) at which these values should be kept,
+// a rate of 1 keeps all such values, 0 drops them all.
+type Sampler func(values map[string]interface{}) float64
+
+var (
+	// sampler is applied to values before they are sent to valueLogs
+	sampler Sampler
+
+	// samplerMu protects sampler
+	samplerMu sync.RWMutex
+
+	// random returns the number compared with the sample rate, replaced in tests
+	random = rand.Float64
+)
+
+// SetSampler sets the sampler applied by Values and ValuesBatch,
+// pass nil to keep all values (the default).
+func SetSampler(s Sampler) {
+	samplerMu.Lock()
+	sampler = s
+	samplerMu.Unlock()
+}
+
+// sample returns values with the sample rate set if they should be kept,
+// or nil if they should be dropped.
+func sample(values map[string]interface{}) map[string]interface{} {
+	samplerMu.RLock()
+	s := sampler
+	samplerMu.RUnlock()
+	if s == nil {
+		return values
+	}
+
+	rate := s(values)
+	if rate >= 1 {
+		return values
+	}
+	if rate <= 0 || random() >= rate {
+		return nil
+	}
+
+	values = copyValues(values)
+	values[KeyNameSampleRate] = rate
+	return values
+}
+
+// RequestSampler samples request values as logged by the logrequest middleware,
+// reading the code and duration keys to keep all errors and slow requests
+// while keeping only a proportion of successful requests.
+type RequestSampler struct {
+	// SuccessRate is the rate at which requests with a status code under 400 are kept
+	SuccessRate float64
+
+	// ErrorRate is the rate at which requests with a status code of 400 or above are kept
+	ErrorRate float64
+
+	// SlowThreshold keeps all requests which take longer than this, if non-zero
+	SlowThreshold time.Duration
+}
+
+// NewRequestSampler returns a Sampler which keeps successRate of successful requests,
+// and all errors and requests slower than slow e.g.
+// log.SetSampler(log.NewRequestSampler(0.01, time.Second))
+func NewRequestSampler(successRate float64, slow time.Duration) Sampler {
+	s := RequestSampler{
+		SuccessRate:   successRate,
+		ErrorRate:     1,
+		SlowThreshold: slow,
+	}
+	return s.Sample
+}
+
+// Sample returns the sample rate for the values given,
+// values which are not requests are always kept.
+func (s RequestSampler) Sample(values map[string]interface{}) float64 {
+	code, ok := values["code"].(int)
+	if !ok {
+		return 1
+	}
+
+	if s.SlowThreshold > 0 {
+		duration, ok := values["duration"].(int64)
+		if ok && time.Duration(duration) > s.SlowThreshold {
+			return 1
+		}
+	}
+
+	if code >= 400 {
+		return s.ErrorRate
+	}
+	return s.SuccessRate
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+// setSampler sets the sampler and the random number compared with sample rates until the test ends
+func setSampler(t *testing.T, s Sampler, n float64) {
+	r := random
+	t.Cleanup(func() {
+		SetSampler(nil)
+		random = r
+	})
+	SetSampler(s)
+	random = func() float64 { return n }
+}
+
+// TestSampleRate tests values are kept or dropped according to the sample rate.
+func TestSampleRate(t *testing.T) {
+	tests := []struct {
+		rate   float64
+		random float64
+		kept   bool
+	}{
+		{0, 0, false},
+		{-1, 0, false},
+		{1, 0.99, true},
+		{2, 0.99, true},
+		{0.5, 0.25, true},
+		{0.5, 0.5, false},
+		{0.5, 0.75, false},
+	}
+
+	for _, tc := range tests {
+		l := addValuesLogger(t)
+		rate := tc.rate
+		setSampler(t, func(values map[string]interface{}) float64 { return rate }, tc.random)
+
+		Values(map[string]interface{}{SeriesName: "requests"})
+		ValuesBatch([]map[string]interface{}{{SeriesName: "requests"}})
+		RemoveValuesLogger(l)
+
+		if !tc.kept {
+			if len(l.values) != 0 {
+				t.Errorf("sample: values kept at rate %v got:%v", tc.rate, l.values)
+			}
+			continue
+		}
+		if len(l.values) != 2 {
+			t.Fatalf("sample: values dropped at rate %v got:%v", tc.rate, l.values)
+		}
+		for _, v := range l.values {
+			r, ok := v[KeyNameSampleRate]
+			if tc.rate < 1 && r != tc.rate || tc.rate >= 1 && ok {
+				t.Errorf("sample: wrong sample rate recorded at rate %v got:%v", tc.rate, v)
+			}
+		}
+	}
+}
+
+// TestRequestSampler tests errors and slow requests are always kept.
+func TestRequestSampler(t *testing.T) {
+	slow := time.Second
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		rate   float64
+	}{
+		{"other", map[string]interface{}{SeriesName: "jobs"}, 1},
+		{"success", map[string]interface{}{"code": 200, "duration": int64(time.Millisecond)}, 0},
+		{"redirect", map[string]interface{}{"code": 302, "duration": int64(slow)}, 0},
+		{"not found", map[string]interface{}{"code": 404, "duration": int64(time.Millisecond)}, 1},
+		{"error", map[string]interface{}{"code": 500}, 1},
+		{"slow", map[string]interface{}{"code": 200, "duration": int64(2 * slow)}, 1},
+	}
+
+	for _, tc := range tests {
+		l := addValuesLogger(t)
+		setSampler(t, NewRequestSampler(0, slow), 0)
+		Values(tc.values)
+		RemoveValuesLogger(l)
+
+		if got := NewRequestSampler(0, slow)(tc.values); got != tc.rate {
+			t.Errorf("sample: wrong rate for %s got:%v want:%v", tc.name, got, tc.rate)
+		}
+		if kept := len(l.values) == 1; kept != (tc.rate == 1) {
+			t.Errorf("sample: wrong values kept for %s got:%v", tc.name, l.values)
+		}
+	}
+
+	s := RequestSampler{SuccessRate: 1, ErrorRate: 0.5}
+	if s.Sample(map[string]interface{}{"code": 200}) != 1 || s.Sample(map[string]interface{}{"code": 503}) != 0.5 {
+		t.Errorf("sample: wrong rates for request sampler")
+	}
+}
@@ -27,6 +27,13 @@ func (l *StatsLog) Values(values map[string]interface{}) {
 	fmt.Printf("Values logged:%+s", values)
 }
 
+// ValuesBatch prints each set of values to stdout.
+func (l *StatsLog) ValuesBatch(values []map[string]interface{}) {
+	for _, v := range values {
+		l.Values(v)
+	}
+}
+
 // AddTag adds a tag field to this
 func AddTag(values map[string]interface{}, key string, value string) map[string]interface{} {
 	values[TagPrefix+key] = value
@@ -0,0 +1,173 @@
+// Package audit records state-changing requests (the actor, route, params and outcome)
+// to the log.Values pipeline, so that they may be sent to an audit sink.
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/middleware/wrap"
+)
+
+// Usage
+// log.AddValuesLogger(sink, log.SeriesFilter(audit.Series))
+// m.AddMiddleware(audit.Middleware)
+
+// These package level variables should be set if required before the middleware is added
+
+// Series is the series name for audit values
+var Series = "audit"
+
+// Methods lists the request methods audited
+var Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// Redact lists substrings of param names whose values are redacted, compared case insensitively
+var Redact = []string{"password", "token", "secret", "key", "card", "cvv"}
+
+// Redacted replaces the values of redacted params
+const Redacted = "[redacted]"
+
+// Actor returns the actor for a request, by default the value
+// stored under mux.KeyUser formatted with fmt.Sprint.
+var Actor = func(r *http.Request) string {
+	user, ok := mux.Value[interface{}](r, mux.KeyUser)
+	if !ok || user == nil {
+		return ""
+	}
+	return fmt.Sprint(user)
+}
+
+// Middleware records an audit entry with log.Values after each audited request.
+// The mux must serve the request so that the route and params are available.
+func Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !audited(r.Method) {
+			h(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := wrap.NewRecorder(w)
+		h(wrap.Wrap(w, rec), r)
+
+		log.Values(Entry(r, rec.Status, time.Since(start)))
+	}
+}
+
+// Entry returns the audit values for a request which has been handled.
+// The path is built from the route pattern with secret params redacted.
+func Entry(r *http.Request, status int, duration time.Duration) map[string]interface{} {
+	values := map[string]interface{}{
+		log.SeriesName:  Series,
+		log.KeyNameTime: time.Now().UTC(),
+		"method":        r.Method,
+		"actor":         Actor(r),
+		"code":          status,
+		"duration":      duration.Nanoseconds(),
+		"remote":        r.RemoteAddr,
+	}
+
+	if id, ok := mux.Value[string](r, mux.KeyRequestID); ok {
+		values["request_id"] = id
+	}
+
+	route := mux.RouteFromContext(r)
+	if route != nil {
+		values["route"] = route.Pattern()
+		values["path"] = routePath(route, r.URL.Path)
+	} else {
+		values["path"] = r.URL.Path
+	}
+
+	// Use the params parsed by the handler if possible, as the body has been read
+	var params url.Values
+	if p, ok := mux.Value[*mux.RequestParams](r, mux.KeyParams); ok && p != nil {
+		params = p.Values
+	} else {
+		params = r.URL.Query()
+		if route != nil {
+			for _, p := range route.ParseInto(r.URL.Path, nil) {
+				params.Set(p.Key, p.Value)
+			}
+		}
+	}
+	values["params"] = redact(params).Encode()
+
+	if err, ok := mux.Value[error](r, mux.KeyError); ok && err != nil {
+		values["error"] = err.Error()
+	}
+
+	return values
+}
+
+// audited returns true if requests with method are audited
+func audited(method string) bool {
+	for _, m := range Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns a copy of params with the values of secret params redacted
+func redact(params url.Values) url.Values {
+	redacted := make(url.Values, len(params))
+	for k, v := range params {
+		if secret(k) {
+			redacted[k] = []string{Redacted}
+			continue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// secret returns true if the param name contains one of the Redact substrings
+func secret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range Redact {
+		if strings.Contains(name, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// routePath returns the path for route with the params in path,
+// with the values of secret params redacted
+func routePath(route mux.Route, path string) string {
+	pattern := route.Pattern()
+	params := route.ParseInto(path, nil)
+	if len(params) == 0 {
+		return path
+	}
+
+	var b strings.Builder
+	level, i := 0, 0
+	for _, c := range pattern {
+		switch {
+		case c == '{':
+			level++
+			if level == 1 && i < len(params) {
+				if secret(params[i].Key) {
+					b.WriteString(Redacted)
+				} else {
+					b.WriteString(params[i].Value)
+				}
+				i++
+			}
+		case c == '}':
+			level--
+		case level == 0:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
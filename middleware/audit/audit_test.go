@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/log"
+)
+
+// recorder records values sent to it
+type recorder struct {
+	values []map[string]interface{}
+}
+
+func (rec *recorder) Values(values map[string]interface{}) {
+	rec.values = append(rec.values, values)
+}
+
+func (rec *recorder) ValuesBatch(values []map[string]interface{}) {
+	rec.values = append(rec.values, values...)
+}
+
+// TestAuditPath tests secret params in the path are redacted, and 1xx responses ignored.
+func TestAuditPath(t *testing.T) {
+	rec := &recorder{}
+	log.AddValuesLogger(rec, log.SeriesFilter(Series))
+	defer log.RemoveValuesLogger(rec)
+
+	m := mux.New()
+	m.AddMiddleware(Middleware)
+	m.Post(`/users/{id:\d+}/reset/{token:[^/]+}`, func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/3/reset/s3cr3t", nil))
+
+	if len(rec.values) != 1 {
+		t.Fatalf("audit: expected 1 entry got:%d", len(rec.values))
+	}
+	entry := rec.values[0]
+	if entry["path"] != "/users/3/reset/"+Redacted || entry["code"] != http.StatusNoContent {
+		t.Errorf("audit: wrong entry got:%v", entry)
+	}
+}
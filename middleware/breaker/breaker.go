@@ -0,0 +1,238 @@
+// Package breaker provides circuit breaking middleware, which fast-fails
+// requests with 503 Service Unavailable after repeated failures,
+// until a probe request succeeds.
+package breaker
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/middleware/wrap"
+)
+
+// Usage
+// b := breaker.New(breaker.Config{Mux: m, Threshold: 5, Window: 10 * time.Second})
+// m.AddMiddleware(b.Middleware)
+// or for a single handler:
+// m.AddHandler("/search", b.Handler("search", handleSearch))
+
+// State is the state of a circuit
+type State int
+
+// States of a circuit
+const (
+	// Closed circuits pass requests to the handler
+	Closed State = iota
+	// Open circuits fast-fail requests until the cooldown ends
+	Open
+	// HalfOpen circuits allow a single probe request through
+	HalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Config represents the config for a Breaker
+type Config struct {
+	Threshold int                        // Failures within Window which open the circuit, default 5
+	Window    time.Duration              // Window in which failures are counted, default 10s
+	Cooldown  time.Duration              // Time the circuit is open before a probe, default 30s
+	Timeout   time.Duration              // Requests slower than this are failures, 0 for none
+	Key       func(*http.Request) string // Key for the circuit, default the route pattern
+
+	// Mux matches requests to routes for the default Key, so that there is one
+	// circuit per route. If nil the route is read from the request if it has been routed.
+	Mux *mux.Mux
+}
+
+// Breaker holds a circuit for each key
+type Breaker struct {
+	config Config
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit records failures for a key
+type circuit struct {
+	state       State
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probing     bool
+}
+
+// New returns a new Breaker with the config given.
+func New(config Config) *Breaker {
+	// Set defaults if none set
+	if config.Threshold == 0 {
+		config.Threshold = 5
+	}
+	if config.Window == 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.Cooldown == 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.Key == nil {
+		config.Key = RouteKey(config.Mux)
+	}
+	return &Breaker{
+		config:   config,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// RouteKey returns a Key function which keys circuits by the pattern of the route
+// matched by m (or stored on the request if m is nil), or "" if no route matches,
+// so that the number of circuits is bounded by the number of routes.
+func RouteKey(m *mux.Mux) func(*http.Request) string {
+	return func(r *http.Request) string {
+		var route mux.Route
+		if m != nil {
+			route = m.RouteFor(r)
+		} else {
+			route = mux.RouteFromContext(r)
+		}
+		if route == nil {
+			return ""
+		}
+		return route.Pattern()
+	}
+}
+
+// Middleware applies the breaker to all requests, with circuits keyed by config.Key.
+func (b *Breaker) Middleware(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b.serve(b.config.Key(r), h, w, r)
+	}
+}
+
+// Handler applies the breaker to a single handler, with one circuit for key.
+func (b *Breaker) Handler(key string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b.serve(key, h, w, r)
+	}
+}
+
+// State returns the state of the circuit for key.
+func (b *Breaker) State(key string) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[key]
+	if !ok {
+		return Closed
+	}
+	if c.state == Open && time.Since(c.openedAt) >= b.config.Cooldown {
+		return HalfOpen
+	}
+	return c.state
+}
+
+// serve calls h if the circuit for key allows it, and records the result.
+// Responses with a 5xx status, or slower than Timeout, and panics are failures.
+func (b *Breaker) serve(key string, h http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	retry, ok := b.allow(key)
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int((retry+time.Second-1)/time.Second)))
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Record the result even if h panics, so that a probe cannot be left in flight
+	start := time.Now()
+	rec := wrap.NewRecorder(w)
+	completed := false
+	defer func() {
+		failed := !completed || rec.Status >= 500 || (b.config.Timeout > 0 && time.Since(start) > b.config.Timeout)
+		b.record(key, failed)
+	}()
+
+	h(wrap.Wrap(w, rec), r)
+	completed = true
+}
+
+// allow returns true if a request may proceed for key,
+// or the time until the next probe if not.
+func (b *Breaker) allow(key string) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[key]
+	if !ok {
+		return 0, true
+	}
+
+	switch c.state {
+	case Open:
+		remaining := b.config.Cooldown - time.Since(c.openedAt)
+		if remaining > 0 {
+			return remaining, false
+		}
+		// Cooldown is over, allow a single probe
+		c.state = HalfOpen
+		c.probing = true
+		return 0, true
+	case HalfOpen:
+		if c.probing {
+			return time.Second, false
+		}
+		c.probing = true
+		return 0, true
+	}
+	return 0, true
+}
+
+// record records the result of a request for key
+func (b *Breaker) record(key string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	c, ok := b.circuits[key]
+
+	if !failed {
+		// Successful probes close the circuit, and idle circuits are removed
+		if ok && (c.state == HalfOpen || (c.state == Closed && now.Sub(c.windowStart) > b.config.Window)) {
+			if c.state == HalfOpen {
+				log.Infof("breaker: circuit closed for %s", key)
+			}
+			delete(b.circuits, key)
+		}
+		return
+	}
+
+	if !ok {
+		c = &circuit{windowStart: now}
+		b.circuits[key] = c
+	}
+
+	switch c.state {
+	case HalfOpen:
+		// Failed probes reopen the circuit
+		c.state = Open
+		c.openedAt = now
+		c.probing = false
+	case Closed:
+		if now.Sub(c.windowStart) > b.config.Window {
+			c.windowStart = now
+			c.failures = 0
+		}
+		c.failures++
+		if c.failures >= b.config.Threshold {
+			c.state = Open
+			c.openedAt = now
+			log.Warnf("breaker: circuit open for %s after %d failures", key, c.failures)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package breaker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux"
+)
+
+// TestBreakerPanic tests a panicking probe is recorded as a failure,
+// so that the circuit does not stay half-open forever.
+func TestBreakerPanic(t *testing.T) {
+	b := New(Config{Threshold: 1, Cooldown: time.Millisecond})
+	fail := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	panics := func(w http.ResponseWriter, r *http.Request) {
+		panic("probe failed")
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	serve := func(h http.HandlerFunc) int {
+		defer func() { recover() }()
+		w := httptest.NewRecorder()
+		b.Handler("key", h)(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	serve(fail)
+	if b.State("key") == Closed {
+		t.Fatalf("breaker: circuit not opened after failure")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	serve(panics)
+	time.Sleep(2 * time.Millisecond)
+	if code := serve(ok); code != http.StatusOK {
+		t.Errorf("breaker: probe not allowed after panicking probe got:%d", code)
+	}
+	if b.State("key") != Closed {
+		t.Errorf("breaker: circuit not closed after successful probe got:%s", b.State("key"))
+	}
+}
+
+// TestBreakerRouteKey tests circuits are keyed by route pattern, with 1xx responses ignored.
+func TestBreakerRouteKey(t *testing.T) {
+	m := mux.New()
+	b := New(Config{Mux: m, Threshold: 2})
+	m.AddMiddleware(b.Middleware)
+	m.Get(`/users/{id:\d+}`, func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusBadGateway)
+		return nil
+	})
+
+	for _, p := range []string{"/users/1", "/users/2"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	if len(b.circuits) != 1 || b.State(`/users/{id:\d+}`) != Open {
+		t.Errorf("breaker: wrong circuits got:%v", b.circuits)
+	}
+}
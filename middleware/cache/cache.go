@@ -0,0 +1,290 @@
+// Package cache caches GET responses for a time, keyed by route pattern and params,
+// in a pluggable store such as memory or redis.
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/store"
+)
+
+// Usage
+// c := cache.New(cache.Config{TTL: time.Minute})
+// m.Get(`/pages/{id:\d+}`, c.Handler(pages.HandleShow))
+// ...
+// c.Invalidate(`/pages/{id:\d+}`)
+
+// Status is the response header reporting whether the response was cached
+const Status = "X-Cache"
+
+// Config represents the config for a Cache
+type Config struct {
+	Store  store.Store              // Store for responses, default a memory store
+	TTL    time.Duration            // Time responses are cached, default 1 minute
+	Prefix string                   // Prefix for keys in the store, default cache:
+	Skip   func(*http.Request) bool // Requests which bypass the cache, default those with credentials
+}
+
+// Cache caches responses in a store
+type Cache struct {
+	config Config
+}
+
+// New returns a new Cache with the config given.
+func New(config Config) *Cache {
+	// Set defaults if none set
+	if config.Store == nil {
+		config.Store = store.NewMemoryStore(10000)
+	}
+	if config.TTL == 0 {
+		config.TTL = time.Minute
+	}
+	if config.Prefix == "" {
+		config.Prefix = "cache:"
+	}
+	if config.Skip == nil {
+		config.Skip = SkipCredentials
+	}
+	return &Cache{config: config}
+}
+
+// SkipCredentials returns true for requests with a Cookie or Authorization header,
+// as responses to these requests may be personalised.
+func SkipCredentials(r *http.Request) bool {
+	return r.Header.Get("Cookie") != "" || r.Header.Get("Authorization") != ""
+}
+
+// Handler caches responses from h, keyed by the pattern of the route
+// matched and the path and query params of the request.
+func (c *Cache) Handler(h mux.HandlerFunc) mux.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		pattern := r.URL.Path
+		values := url.Values{}
+		if route := mux.RouteFromContext(r); route != nil {
+			pattern = route.Pattern()
+			for _, p := range route.ParseInto(r.URL.Path, nil) {
+				values.Add(p.Key, p.Value)
+			}
+		}
+		for k, v := range r.URL.Query() {
+			values[k] = append(values[k], v...)
+		}
+		return c.serve(pattern, values, h, w, r)
+	}
+}
+
+// Middleware caches all responses, keyed by the request path and query,
+// pass the path to Invalidate to remove responses for it.
+func (c *Cache) Middleware(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c.serve(r.URL.Path, r.URL.Query(), func(w http.ResponseWriter, r *http.Request) error {
+			h(w, r)
+			return nil
+		}, w, r)
+	}
+}
+
+// Invalidate removes all cached responses for the route pattern
+// (or the path for responses cached by Middleware).
+func (c *Cache) Invalidate(pattern string) error {
+	return c.config.Store.Set(c.generationKey(pattern), newGeneration(), c.config.TTL)
+}
+
+// serve writes a cached response if there is one, otherwise calls h and caches the response.
+// HEAD requests are served from cached GET responses, but their responses are not cached.
+func (c *Cache) serve(pattern string, values url.Values, h mux.HandlerFunc, w http.ResponseWriter, r *http.Request) error {
+	if (r.Method != http.MethodGet && r.Method != http.MethodHead) || c.config.Skip(r) {
+		return h(w, r)
+	}
+
+	key, generationKey := c.key(pattern, values)
+
+	// Responses are stored under a key including the request headers they vary by,
+	// which are stored under the base key
+	vary, ok := c.vary(key)
+	if ok {
+		data, ok, err := c.config.Store.Get(variantKey(key, vary, r))
+		if err == nil && ok {
+			res, err := decode(data)
+			if err == nil {
+				res.writeTo(w, "HIT")
+				return nil
+			}
+		}
+	}
+
+	// Responses to HEAD requests have no body, so must not be stored
+	if r.Method == http.MethodHead {
+		return h(w, r)
+	}
+
+	rec := &response{header: make(http.Header), status: http.StatusOK}
+	err := h(rec, r)
+	if err != nil {
+		if rec.wroteHeader {
+			rec.writeTo(w, "")
+		}
+		return err
+	}
+
+	if cacheable(rec) {
+		c.store(key, generationKey, rec, r)
+	}
+	rec.writeTo(w, "MISS")
+	return nil
+}
+
+// store saves the response and the headers it varies by, and extends the
+// generation so that it does not expire before the response
+func (c *Cache) store(key, generationKey string, res *response, r *http.Request) {
+	var vary []string
+	for _, v := range res.header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name != "" {
+				vary = append(vary, name)
+			}
+		}
+	}
+	sort.Strings(vary)
+
+	data, err := encode(res)
+	if err != nil {
+		return
+	}
+	c.config.Store.Expire(generationKey, c.config.TTL)
+	c.config.Store.Set(key, []byte(strings.Join(vary, ",")), c.config.TTL)
+	c.config.Store.Set(variantKey(key, vary, r), data, c.config.TTL)
+}
+
+// vary returns the headers the response for key varies by, if it is cached
+func (c *Cache) vary(key string) ([]string, bool) {
+	data, ok, err := c.config.Store.Get(key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	if len(data) == 0 {
+		return nil, true
+	}
+	return strings.Split(string(data), ","), true
+}
+
+// key returns the base key for the pattern and values, and the key of the generation
+// it includes, so that Invalidate removes all entries. If the generation has expired
+// or been evicted a new one is started, so that earlier entries are never used again.
+func (c *Cache) key(pattern string, values url.Values) (string, string) {
+	generationKey := c.generationKey(pattern)
+	generation, ok, err := c.config.Store.Get(generationKey)
+	if err == nil && !ok {
+		generation = newGeneration()
+		c.config.Store.Set(generationKey, generation, c.config.TTL)
+	}
+	return c.config.Prefix + pattern + "#" + string(generation) + "?" + values.Encode(), generationKey
+}
+
+// newGeneration returns a new generation value
+func newGeneration() []byte {
+	return []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+}
+
+// generationKey returns the key storing the generation of pattern
+func (c *Cache) generationKey(pattern string) string {
+	return c.config.Prefix + "generation:" + pattern
+}
+
+// variantKey returns the key for a response varying by the headers given
+func variantKey(key string, vary []string, r *http.Request) string {
+	if len(vary) == 0 {
+		return key + "#"
+	}
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteByte('#')
+		b.WriteString(r.Header.Get(name))
+	}
+	return b.String()
+}
+
+// cacheable returns true if the response may be shared with other clients
+func cacheable(res *response) bool {
+	if res.status != http.StatusOK || res.header.Get("Set-Cookie") != "" || res.header.Get("Vary") == "*" {
+		return false
+	}
+	cc := strings.ToLower(res.header.Get("Cache-Control"))
+	return !strings.Contains(cc, "private") && !strings.Contains(cc, "no-store")
+}
+
+// response buffers a response so that it may be stored and written later
+type response struct {
+	header      http.Header
+	status      int
+	body        []byte
+	wroteHeader bool
+}
+
+// Header returns the response headers
+func (res *response) Header() http.Header {
+	return res.header
+}
+
+// WriteHeader records the status
+func (res *response) WriteHeader(code int) {
+	if res.wroteHeader {
+		return
+	}
+	res.status = code
+	res.wroteHeader = true
+}
+
+// Write buffers the body
+func (res *response) Write(b []byte) (int, error) {
+	res.wroteHeader = true
+	res.body = append(res.body, b...)
+	return len(b), nil
+}
+
+// writeTo writes the response to w with the cache status given
+func (res *response) writeTo(w http.ResponseWriter, status string) {
+	header := w.Header()
+	for k, v := range res.header {
+		header[k] = append([]string(nil), v...)
+	}
+	if status != "" {
+		header.Set(Status, status)
+	}
+	w.WriteHeader(res.status)
+	w.Write(res.body)
+}
+
+// entry is the stored form of a response
+type entry struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// encode serialises a response for storage
+func encode(res *response) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(entry{Status: res.status, Header: res.header, Body: res.body})
+	return buf.Bytes(), err
+}
+
+// decode deserialises a stored response
+func decode(data []byte) (*response, error) {
+	var e entry
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e)
+	if err != nil {
+		return nil, err
+	}
+	return &response{header: e.Header, status: e.Status, body: e.Body}, nil
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/store"
+)
+
+// serve serves a request for path with method and returns the response
+func serve(m *mux.Mux, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// TestCacheHead tests responses to HEAD requests are not stored,
+// and HEAD requests are served from cached GET responses.
+func TestCacheHead(t *testing.T) {
+	c := New(Config{TTL: time.Minute})
+	m := mux.New()
+	m.AddMiddleware(c.Middleware)
+	m.AddHandler("/p", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world"))
+	})
+
+	serve(m, http.MethodHead, "/p")
+	w := serve(m, http.MethodGet, "/p")
+	if w.Body.String() != "hello world" || w.Header().Get(Status) != "MISS" {
+		t.Errorf("cache: wrong GET after HEAD got:%q %s", w.Body.String(), w.Header().Get(Status))
+	}
+
+	w = serve(m, http.MethodHead, "/p")
+	if w.Header().Get(Status) != "HIT" {
+		t.Errorf("cache: HEAD not served from cached GET got:%s", w.Header().Get(Status))
+	}
+	w = serve(m, http.MethodGet, "/p")
+	if w.Body.String() != "hello world" || w.Header().Get(Status) != "HIT" {
+		t.Errorf("cache: wrong GET after cached HEAD got:%q %s", w.Body.String(), w.Header().Get(Status))
+	}
+}
+
+// TestCacheInvalidate tests invalidated responses are not served,
+// even if the generation is later removed from the store.
+func TestCacheInvalidate(t *testing.T) {
+	s := store.NewMemoryStore(0)
+	c := New(Config{Store: s, TTL: time.Minute})
+	body := "first"
+	m := mux.New()
+	m.Get(`/pages/{id:\d+}`, c.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		_, err := w.Write([]byte(body))
+		return err
+	}))
+
+	serve(m, http.MethodGet, "/pages/1")
+	body = "second"
+	if w := serve(m, http.MethodGet, "/pages/1"); w.Body.String() != "first" {
+		t.Errorf("cache: response not cached got:%q", w.Body.String())
+	}
+
+	c.Invalidate(`/pages/{id:\d+}`)
+	if w := serve(m, http.MethodGet, "/pages/1"); w.Body.String() != "second" {
+		t.Errorf("cache: invalidated response served got:%q", w.Body.String())
+	}
+
+	// Evicting the generation must not restore earlier responses
+	body = "third"
+	s.Delete(c.generationKey(`/pages/{id:\d+}`))
+	if w := serve(m, http.MethodGet, "/pages/1"); w.Body.String() != "third" {
+		t.Errorf("cache: response served after generation evicted got:%q", w.Body.String())
+	}
+}
@@ -0,0 +1,158 @@
+// Package coalesce deduplicates concurrent identical GET requests, so that
+// only one handler runs and its response is sent to every waiting client.
+package coalesce
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Usage
+// m.AddMiddleware(coalesce.New().Middleware)
+
+// These package level variables should be set if required before the middleware is added
+
+// SkipCredentials skips coalescing for requests with a Cookie or Authorization header,
+// as responses to these requests may be personalised.
+var SkipCredentials = true
+
+// KeyHeaders lists request headers included in the key, so that requests
+// which may receive different representations are not coalesced.
+var KeyHeaders = []string{"Accept", "Accept-Encoding", "Accept-Language"}
+
+// Coalesced is the response header set on responses shared with a waiting request
+const Coalesced = "X-Coalesced"
+
+// Group coalesces requests, requests are only coalesced with others in the same group.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// New returns a new group, each mux should use its own group.
+func New() *Group {
+	return &Group{calls: make(map[string]*call)}
+}
+
+// Middleware runs the handler once for concurrent GET requests with the same key,
+// buffering the response and copying it to every request waiting on it.
+// Responses are buffered, so this should not be used for streaming endpoints.
+func (g *Group) Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || (SkipCredentials && (r.Header.Get("Cookie") != "" || r.Header.Get("Authorization") != "")) {
+			h(w, r)
+			return
+		}
+
+		res, shared := g.do(Key(r), func() *response {
+			rec := &response{header: make(http.Header), status: http.StatusOK}
+			h(rec, r)
+			// The response to a cancelled request may be incomplete
+			rec.cancelled = r.Context().Err() != nil
+			return rec
+		})
+
+		// If the handler panicked or was cancelled for the leader, or set cookies
+		// which must not be shared, waiters run the handler themselves
+		if shared && (res == nil || res.cancelled || len(res.header["Set-Cookie"]) > 0) {
+			h(w, r)
+			return
+		}
+		res.writeTo(w, shared)
+	}
+}
+
+// Key returns the key used to coalesce a request, the path with
+// sorted query params and the values of KeyHeaders.
+func Key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.Host)
+	b.WriteString(r.URL.Path)
+	if r.URL.RawQuery != "" {
+		b.WriteByte('?')
+		b.WriteString(r.URL.Query().Encode())
+	}
+	for _, k := range KeyHeaders {
+		b.WriteByte('\n')
+		b.WriteString(r.Header.Get(k))
+	}
+	return b.String()
+}
+
+// call is a handler call in flight or completed
+type call struct {
+	done chan struct{}
+	res  *response
+}
+
+// do calls fn once for concurrent calls with the same key, and returns
+// its result to all callers, with shared true for callers which waited.
+// Calls are deduplicated as in golang.org/x/sync/singleflight.
+func (g *Group) do(key string, fn func() *response) (*response, bool) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.res, true
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// Ensure waiters are released even if fn panics
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.res = fn()
+	return c.res, false
+}
+
+// response buffers a response so that it may be copied to several writers
+type response struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	cancelled   bool
+	body        bytes.Buffer
+}
+
+// Header returns the response headers
+func (res *response) Header() http.Header {
+	return res.header
+}
+
+// WriteHeader records the status, informational responses are discarded
+// as they cannot be sent after the handler has finished
+func (res *response) WriteHeader(code int) {
+	if res.wroteHeader || code < 200 {
+		return
+	}
+	res.status = code
+	res.wroteHeader = true
+}
+
+// Write buffers the body
+func (res *response) Write(b []byte) (int, error) {
+	res.wroteHeader = true
+	return res.body.Write(b)
+}
+
+// writeTo copies the response to w, the buffered response is not modified
+func (res *response) writeTo(w http.ResponseWriter, shared bool) {
+	header := w.Header()
+	for k, v := range res.header {
+		header[k] = append([]string(nil), v...)
+	}
+	if shared {
+		header.Set(Coalesced, "1")
+	}
+	w.WriteHeader(res.status)
+	w.Write(res.body.Bytes())
+}
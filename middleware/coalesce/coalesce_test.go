@@ -0,0 +1,118 @@
+package coalesce
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCoalesce tests concurrent requests share one response, with 1xx responses discarded.
+func TestCoalesce(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	h := New().Middleware(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	})
+
+	codes := serveConcurrently(h, 3, nil, release)
+	if calls.Load() != 1 {
+		t.Errorf("coalesce: expected 1 call got:%d", calls.Load())
+	}
+	for _, code := range codes {
+		if code != http.StatusNotFound {
+			t.Errorf("coalesce: wrong status got:%d", code)
+		}
+	}
+}
+
+// TestCoalesceCancelled tests the response to a cancelled leader is not shared.
+func TestCoalesceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	h := New().Middleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context() == ctx {
+			<-release
+			cancel()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	codes := serveConcurrently(h, 3, ctx, release)
+	for _, code := range codes[1:] {
+		if code != http.StatusOK {
+			t.Errorf("coalesce: waiter received response of cancelled leader got:%d", code)
+		}
+	}
+}
+
+// TestCoalesceGroups tests requests are not coalesced across groups.
+func TestCoalesceGroups(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}
+	a, b := New().Middleware(handler), New().Middleware(handler)
+
+	var wg sync.WaitGroup
+	for _, h := range []http.HandlerFunc{a, b} {
+		wg.Add(1)
+		go func(h http.HandlerFunc) {
+			defer wg.Done()
+			h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}(h)
+	}
+	waitFor(func() bool { return calls.Load() == 2 })
+	close(release)
+	wg.Wait()
+	if calls.Load() != 2 {
+		t.Errorf("coalesce: requests coalesced across groups got:%d calls", calls.Load())
+	}
+}
+
+// serveConcurrently serves n requests for / concurrently, the first (the leader)
+// with ctx if not nil, and returns their status codes once release is closed.
+func serveConcurrently(h http.HandlerFunc, n int, ctx context.Context, release chan struct{}) []int {
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if i == 0 && ctx != nil {
+			r = r.WithContext(ctx)
+		}
+		wg.Add(1)
+		go func(i int, r *http.Request) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			h(w, r)
+			codes[i] = w.Code
+		}(i, r)
+
+		// Start the leader before the waiters
+		if i == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return codes
+}
+
+// waitFor waits up to a second for cond to be true
+func waitFor(cond func() bool) {
+	for i := 0; i < 100 && !cond(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,100 @@
+// Package decompress provides middleware which transparently decompresses
+// request bodies sent with a gzip or deflate Content-Encoding.
+package decompress
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Usage
+// m.AddMiddleware(decompress.Middleware)
+
+// These package level variables should be set if required before the middleware is added
+
+// MaxSize is the maximum size of a decompressed body in bytes, reading
+// beyond this returns an error to the handler (as with http.MaxBytesReader).
+var MaxSize int64 = 10 << 20 // 10MB
+
+// Middleware decompresses request bodies with a Content-Encoding of gzip or deflate,
+// so that Params and handlers read the decompressed body. The Content-Encoding and
+// Content-Length headers are removed from the request.
+// Requests with other encodings are rejected with 415 Unsupported Media Type,
+// and requests with corrupt bodies with 400 Bad Request.
+func Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Content-Encoding")
+		if header == "" || r.Body == nil || r.Body == http.NoBody {
+			h(w, r)
+			return
+		}
+
+		// Encodings are listed in the order applied, so remove them in reverse
+		encodings := strings.Split(header, ",")
+		body := r.Body
+		for i := len(encodings) - 1; i >= 0; i-- {
+			encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+			var err error
+			switch encoding {
+			case "gzip", "x-gzip":
+				body, err = gzipReader(body)
+			case "deflate":
+				body, err = deflateReader(body)
+			case "identity", "":
+				continue
+			default:
+				http.Error(w, "unsupported content encoding", http.StatusUnsupportedMediaType)
+				return
+			}
+			if err != nil {
+				http.Error(w, "invalid compressed body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		r.Body = http.MaxBytesReader(w, &readCloser{Reader: body, closer: r.Body}, MaxSize)
+		r.ContentLength = -1
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+
+		h(w, r)
+	}
+}
+
+// gzipReader returns a reader decompressing gzip data from r
+func gzipReader(r io.ReadCloser) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateReader returns a reader decompressing deflate data from r, which
+// should be zlib wrapped, though some clients send raw deflate data instead.
+func deflateReader(r io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// A zlib header has compression method 8 and is a multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// readCloser reads decompressed data and closes the original body
+type readCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+// Close closes the original body
+func (rc *readCloser) Close() error {
+	return rc.closer.Close()
+}
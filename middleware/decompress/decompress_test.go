@@ -0,0 +1,115 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fragmenta/mux"
+)
+
+// encode returns data compressed with each of the encodings in turn
+func encode(data []byte, encodings ...string) []byte {
+	for _, e := range encodings {
+		b := &bytes.Buffer{}
+		var w io.WriteCloser
+		switch e {
+		case "gzip":
+			w = gzip.NewWriter(b)
+		case "zlib":
+			w = zlib.NewWriter(b)
+		case "flate":
+			w, _ = flate.NewWriter(b, flate.DefaultCompression)
+		}
+		w.Write(data)
+		w.Close()
+		data = b.Bytes()
+	}
+	return data
+}
+
+// TestMiddleware tests compressed bodies are decoded for handlers.
+func TestMiddleware(t *testing.T) {
+	body := []byte("name=test&value=decompressed")
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		code     int
+	}{
+		{"plain", "", body, http.StatusOK},
+		{"identity", "identity", body, http.StatusOK},
+		{"gzip", "gzip", encode(body, "gzip"), http.StatusOK},
+		{"x-gzip", "x-gzip", encode(body, "gzip"), http.StatusOK},
+		{"zlib", "deflate", encode(body, "zlib"), http.StatusOK},
+		{"raw deflate", "deflate", encode(body, "flate"), http.StatusOK},
+		{"stacked", "gzip, gzip", encode(body, "gzip", "gzip"), http.StatusOK},
+		{"mixed", "deflate, GZIP", encode(body, "zlib", "gzip"), http.StatusOK},
+		{"unknown", "br", body, http.StatusUnsupportedMediaType},
+		{"unknown stacked", "br, gzip", encode(body, "gzip"), http.StatusUnsupportedMediaType},
+		{"corrupt gzip", "gzip", body, http.StatusBadRequest},
+		{"corrupt deflate", "deflate", []byte("x"), http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		var got []byte
+		h := Middleware(func(w http.ResponseWriter, r *http.Request) {
+			if tc.encoding != "" && (r.Header.Get("Content-Encoding") != "" || r.ContentLength != -1) {
+				t.Errorf("decompress %s: encoding headers not removed got:%v %d", tc.name, r.Header, r.ContentLength)
+			}
+			got, _ = io.ReadAll(r.Body)
+		})
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tc.body))
+		r.Header.Set("Content-Encoding", tc.encoding)
+		h(w, r)
+		if w.Code != tc.code {
+			t.Errorf("decompress %s: wrong status got:%d want:%d", tc.name, w.Code, tc.code)
+		}
+		if tc.code == http.StatusOK && !bytes.Equal(got, body) {
+			t.Errorf("decompress %s: wrong body got:%q want:%q", tc.name, got, body)
+		}
+		if tc.code != http.StatusOK && got != nil {
+			t.Errorf("decompress %s: handler called for rejected body", tc.name)
+		}
+	}
+}
+
+// TestMaxSize tests bodies decompressing to more than MaxSize are rejected.
+func TestMaxSize(t *testing.T) {
+	defer func(size int64) { MaxSize = size }(MaxSize)
+	MaxSize = 1 << 10
+
+	m := mux.New()
+	m.AddMiddleware(Middleware)
+	m.Post("/", func(w http.ResponseWriter, r *http.Request) error {
+		_, err := mux.Params(r)
+		return err
+	})
+
+	tests := []struct {
+		size int
+		code int
+	}{
+		{512, http.StatusOK},
+		{1 << 20, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		body := encode([]byte("name="+strings.Repeat("a", tc.size)), "gzip")
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Content-Encoding", "gzip")
+		m.ServeHTTP(w, r)
+		if w.Code != tc.code {
+			t.Errorf("decompress: wrong status for %d bytes (%d compressed) got:%d want:%d", tc.size, len(body), w.Code, tc.code)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"compress/gzip"
 	"io"
 	"net/http"
-	"strings"
+
+	"github.com/fragmenta/mux/middleware/wrap"
+	"github.com/fragmenta/mux/negotiate"
 )
 
 // This middleware provides gzip compression on requests where the client accepts it
@@ -18,7 +20,7 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		// If gzip not accepted, execute the handler without compression and return
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		if negotiate.Encoding(r.Header.Get("Accept-Encoding"), "gzip", "identity") != "gzip" {
 			h(w, r)
 			return
 		}
@@ -31,30 +33,14 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 		gw, _ := gzip.NewWriterLevel(w, gzip.DefaultCompression)
 		defer gw.Close()
 
-		// Store Hijacker, Flusher, Notifier if appropriate
-		hijacker, hok := w.(http.Hijacker)
-		if !hok {
-			hijacker = nil
-		}
-
-		flusher, fok := w.(http.Flusher)
-		if !fok {
-			flusher = nil
-		}
-
-		notifier, cnok := w.(http.CloseNotifier)
-		if !cnok {
-			notifier = nil
-		}
-
-		// Replace the writer with the compressed response writer
-		w = &compressResponseWriter{
+		// Replace the writer with the compressed response writer,
+		// exposing Hijacker, Flusher and Pusher only if w supports them.
+		// The deprecated http.CloseNotifier is not exposed, use r.Context() instead.
+		cw := &compressResponseWriter{
 			Writer:         gw,
 			ResponseWriter: w,
-			Hijacker:       hijacker,
-			Flusher:        flusher,
-			CloseNotifier:  notifier,
 		}
+		w = wrap.Wrap(w, cw)
 
 		// Call the handler with the new writer
 		h(w, r)
@@ -65,9 +51,6 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 type compressResponseWriter struct {
 	io.Writer
 	http.ResponseWriter
-	http.Hijacker
-	http.Flusher
-	http.CloseNotifier
 }
 
 // WriteHeader writes the header and zeroes content length if set
@@ -104,7 +87,7 @@ func (w *compressResponseWriter) Flush() {
 		f.Flush()
 	}
 	// Flush HTTP response.
-	if w.Flusher != nil {
-		w.Flusher.Flush()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
 	}
 }
@@ -0,0 +1,140 @@
+// Package i18n resolves the locale for each request from the path, query, cookie
+// or Accept-Language header, and stores it on the request under mux.KeyLocale.
+package i18n
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/negotiate"
+)
+
+// Usage
+// i18n.Locales = []string{"en", "fr", "de"}
+// m.AddMiddleware(i18n.Middleware)
+// ...
+// locale := i18n.Locale(r)
+
+// Source is a source of the request locale
+type Source int
+
+// Sources of the request locale
+const (
+	// Path reads the locale from the first path segment e.g. /fr/pages
+	Path Source = iota
+	// Query reads the locale from the query param QueryParam
+	Query
+	// Cookie reads the locale from the cookie CookieName
+	Cookie
+	// Header reads the locale from the Accept-Language header
+	Header
+)
+
+// These package level variables should be set if required before the middleware is added
+
+// Locales lists the locales supported, the first is used as the default
+var Locales = []string{"en"}
+
+// Sources lists the sources of the locale in priority order
+var Sources = []Source{Path, Query, Cookie, Header}
+
+// QueryParam is the name of the query param read for the locale
+var QueryParam = "locale"
+
+// CookieName is the name of the cookie read for the locale
+var CookieName = "locale"
+
+// StripPrefix removes the locale prefix from the path before routing
+// if the locale was read from the path, so /fr/pages is routed as /pages.
+var StripPrefix = true
+
+// FieldLocale is the request log field for the locale
+const FieldLocale = "locale"
+
+// Middleware resolves the locale for the request and stores it under mux.KeyLocale,
+// falling back to the default locale if no source gives a supported locale.
+func Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(Locales) == 0 {
+			h(w, r)
+			return
+		}
+
+		locale := ""
+		for _, source := range Sources {
+			switch source {
+			case Path:
+				locale = pathLocale(r.URL.Path)
+			case Query:
+				locale = match(r.URL.Query().Get(QueryParam))
+			case Cookie:
+				if c, err := r.Cookie(CookieName); err == nil {
+					locale = match(c.Value)
+				}
+			case Header:
+				// Responses vary by header if it is consulted
+				w.Header().Add("Vary", "Accept-Language")
+				locale = headerLocale(r.Header.Get("Accept-Language"))
+			}
+			if locale != "" {
+				if source == Path && StripPrefix {
+					r = mux.StripPrefix(r, "/"+locale)
+				}
+				break
+			}
+		}
+		if locale == "" {
+			locale = Locales[0]
+		}
+
+		r = mux.WithValues(r)
+		mux.SetValue(r, mux.KeyLocale, locale)
+		log.SetField(r, FieldLocale, locale)
+
+		h(w, r)
+	}
+}
+
+// Locale returns the locale for this request, or the default locale if none is set.
+func Locale(r *http.Request) string {
+	locale, ok := mux.Value[string](r, mux.KeyLocale)
+	if !ok && len(Locales) > 0 {
+		return Locales[0]
+	}
+	return locale
+}
+
+// match returns the supported locale for s, comparing case insensitively,
+// or "" if s is not supported.
+func match(s string) string {
+	if s == "" {
+		return ""
+	}
+	for _, l := range Locales {
+		if strings.EqualFold(l, s) {
+			return l
+		}
+	}
+	return ""
+}
+
+// pathLocale returns the supported locale in the first segment of path, if any
+func pathLocale(path string) string {
+	segment := strings.TrimPrefix(path, "/")
+	if i := strings.IndexByte(segment, '/'); i >= 0 {
+		segment = segment[:i]
+	}
+	return match(segment)
+}
+
+// headerLocale returns the supported locale preferred by an Accept-Language header,
+// matching exact tags first and then the base language e.g. en-GB matches en.
+func headerLocale(header string) string {
+	if strings.TrimSpace(header) == "" {
+		return ""
+	}
+	return negotiate.Language(header, Locales...)
+}
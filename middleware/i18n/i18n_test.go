@@ -0,0 +1,37 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fragmenta/mux"
+)
+
+// TestMiddleware tests the locale is stored on the request and stripped from the path.
+func TestMiddleware(t *testing.T) {
+	Locales = []string{"en", "fr"}
+	defer func() { Locales = []string{"en"} }()
+
+	m := mux.New()
+	m.AddMiddleware(Middleware)
+	var locale, value string
+	m.Get("/pages", func(w http.ResponseWriter, r *http.Request) error {
+		locale = Locale(r)
+		value, _ = mux.Value[string](r, mux.KeyLocale)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fr/pages", nil))
+	if w.Code != http.StatusOK || locale != "fr" || value != "fr" {
+		t.Errorf("i18n: wrong locale got:%d %q %q", w.Code, locale, value)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/pages", nil)
+	r.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+	m.ServeHTTP(httptest.NewRecorder(), r)
+	if locale != "fr" {
+		t.Errorf("i18n: wrong locale from header got:%q", locale)
+	}
+}
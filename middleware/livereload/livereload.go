@@ -0,0 +1,365 @@
+// Package livereload reloads pages in the browser during development when
+// templates or assets change, by injecting a small script into html responses
+// which listens on a websocket for change notifications.
+package livereload
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/middleware/wrap"
+)
+
+// Usage
+// reload := livereload.New(livereload.Config{Dirs: []string{"src", "public"}})
+// defer reload.Close()
+// m.AddMiddleware(reload.Middleware)
+
+// Config sets the directories watched by a Reloader and the path it listens on.
+type Config struct {
+	// Dirs lists directories which are watched for changes
+	Dirs []string
+
+	// Interval is the time between scans of Dirs, defaults to 500ms
+	Interval time.Duration
+
+	// Path is the websocket endpoint browsers connect to, defaults to /_livereload
+	Path string
+}
+
+// Reloader watches directories and notifies connected browsers of changes.
+type Reloader struct {
+	config Config
+	script []byte
+
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+
+	done chan struct{}
+	once sync.Once
+}
+
+// New returns a Reloader watching the directories in config.
+// Close should be called to stop watching.
+func New(config Config) *Reloader {
+	if config.Interval == 0 {
+		config.Interval = 500 * time.Millisecond
+	}
+	if config.Path == "" {
+		config.Path = "/_livereload"
+	}
+
+	l := &Reloader{
+		config:  config,
+		script:  []byte(strings.Replace(script, "PATH", strconv.Quote(config.Path), 1)),
+		clients: make(map[chan struct{}]bool),
+		done:    make(chan struct{}),
+	}
+	go l.watch()
+	return l
+}
+
+// Close stops watching for changes and disconnects browsers.
+func (l *Reloader) Close() {
+	l.once.Do(func() {
+		close(l.done)
+	})
+}
+
+// Reload notifies all connected browsers to reload.
+func (l *Reloader) Reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for c := range l.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Middleware serves the websocket endpoint at Path, and injects
+// the reload script into html responses before the closing body tag.
+// Html responses are buffered, so this should only be used in development.
+func (l *Reloader) Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == l.config.Path {
+			l.serveSocket(w, r)
+			return
+		}
+
+		iw := &injectWriter{ResponseWriter: w, script: l.script}
+		h(wrap.Wrap(w, iw), r)
+		iw.flush()
+	}
+}
+
+// watch scans the directories every Interval and reloads browsers on changes
+func (l *Reloader) watch() {
+	ticker := time.NewTicker(l.config.Interval)
+	defer ticker.Stop()
+
+	last := l.scan()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			current := l.scan()
+			if current != last {
+				log.Infof("livereload: files changed, reloading")
+				last = current
+				l.Reload()
+			}
+		}
+	}
+}
+
+// snapshot summarises the state of watched files, a change to
+// any file will change either the latest time or the count.
+type snapshot struct {
+	latest time.Time
+	count  int
+}
+
+// scan walks the watched directories and returns a snapshot of them
+func (l *Reloader) scan() snapshot {
+	var s snapshot
+	for _, dir := range l.config.Dirs {
+		filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			s.count++
+			if info.ModTime().After(s.latest) {
+				s.latest = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return s
+}
+
+// websocketGUID is used to compute the accept key in the websocket handshake (RFC 6455)
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// serveSocket upgrades the request to a websocket and sends a
+// reload message to the browser whenever files change.
+func (l *Reloader) serveSocket(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "livereload: websocket required", http.StatusBadRequest)
+		return
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "livereload: websocket not supported", http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	c := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.clients[c] = true
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, c)
+		l.mu.Unlock()
+	}()
+
+	// Browsers send nothing but a close frame, so stop when the read fails
+	closed := make(chan struct{})
+	go func() {
+		discardFrames(rw.Reader)
+		close(closed)
+	}()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-closed:
+			return
+		case <-c:
+			if err := writeText(conn, "reload"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeText writes an unmasked websocket text frame containing msg,
+// using the extended payload lengths for messages of 126 bytes or more.
+func writeText(conn net.Conn, msg string) error {
+	frame := []byte{0x81, 0}
+	switch n := len(msg); {
+	case n < 126:
+		frame[1] = byte(n)
+	case n <= 0xffff:
+		frame[1] = 126
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame[1] = 127
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	_, err := conn.Write(append(frame, msg...))
+	return err
+}
+
+// discardFrames reads frames from r until a close frame or an error
+func discardFrames(r *bufio.Reader) {
+	var header [2]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return
+		}
+		if header[0]&0x0f == 0x8 {
+			return
+		}
+
+		// Read the payload length, then skip the mask and payload
+		n := uint64(header[1] & 0x7f)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if header[1]&0x80 != 0 {
+			n += 4
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil {
+			return
+		}
+	}
+}
+
+// injectWriter buffers html responses so that the script can be inserted,
+// other responses are written through unchanged.
+type injectWriter struct {
+	http.ResponseWriter
+	script []byte
+
+	decided bool
+	inject  bool
+	status  int
+	buf     bytes.Buffer
+}
+
+// WriteHeader decides whether to buffer the response, and records the status if so.
+// Informational (1xx) responses are passed through without deciding.
+func (w *injectWriter) WriteHeader(status int) {
+	if w.decided {
+		return
+	}
+	if status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.decided = true
+	h := w.Header()
+	w.inject = strings.HasPrefix(h.Get("Content-Type"), "text/html") && h.Get("Content-Encoding") == ""
+	if !w.inject {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+// Write buffers html, or writes other content through
+func (w *injectWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.inject {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush flushes responses which are not buffered
+func (w *injectWriter) Flush() {
+	if !w.decided {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.inject {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// flush writes the buffered html with the script inserted
+func (w *injectWriter) flush() {
+	if !w.inject {
+		return
+	}
+	body := w.buf.Bytes()
+	if i := bytes.LastIndex(bytes.ToLower(body), []byte("</body>")); i >= 0 {
+		body = append(body[:i:i], append(w.script, body[i:]...)...)
+	} else {
+		body = append(body, w.script...)
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Cache-Control", "no-store")
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		log.Errorf("livereload: error writing response %s", err)
+	}
+}
+
+// script connects to the websocket and reloads the page on a message,
+// or when the server comes back after a restart.
+const script = `<script>
+(function() {
+	var lost = false;
+	function connect() {
+		var ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + PATH);
+		ws.onopen = function() { if (lost) { location.reload(); } };
+		ws.onmessage = function() { location.reload(); };
+		ws.onclose = function() { lost = true; setTimeout(connect, 1000); };
+	}
+	connect();
+})();
+</script>
+`
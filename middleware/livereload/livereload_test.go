@@ -0,0 +1,133 @@
+package livereload
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestInject tests the script is inserted into html responses only.
+func TestInject(t *testing.T) {
+	l := New(Config{})
+	defer l.Close()
+
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"text/html; charset=utf-8", "<html><body>hello</body></html>", "<html><body>hello" + string(l.script) + "</body></html>"},
+		{"text/html; charset=utf-8", "hello", "hello" + string(l.script)},
+		{"application/json", `{"a":1}`, `{"a":1}`},
+	}
+
+	for _, tc := range tests {
+		h := l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tc.contentType)
+			w.Write([]byte(tc.body))
+		})
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Body.String() != tc.want {
+			t.Errorf("livereload: wrong body for %s got:%q want:%q", tc.contentType, w.Body.String(), tc.want)
+		}
+	}
+}
+
+// TestInjectEarlyHints tests informational responses do not replace the final status.
+func TestInjectEarlyHints(t *testing.T) {
+	l := New(Config{})
+	defer l.Close()
+
+	h := l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<body>missing</body>"))
+	})
+
+	// Use a server, as httptest.ResponseRecorder records 1xx status codes as final
+	s := httptest.NewServer(h)
+	defer s.Close()
+	res, err := http.Get(s.URL)
+	if err != nil {
+		t.Fatalf("livereload: error getting page %s", err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("livereload: wrong status got:%d want:%d", res.StatusCode, http.StatusNotFound)
+	}
+	if !strings.Contains(string(body), "WebSocket") {
+		t.Errorf("livereload: script not injected got:%q", body)
+	}
+}
+
+// TestInjectFlusher tests the wrapped writer supports flushing.
+func TestInjectFlusher(t *testing.T) {
+	l := New(Config{})
+	defer l.Close()
+
+	h := l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Errorf("livereload: writer does not support http.Flusher")
+		}
+	})
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// pipe returns a connection reading the frames written by write
+func pipe(write func(conn net.Conn)) net.Conn {
+	server, client := net.Pipe()
+	go func() {
+		write(server)
+		server.Close()
+	}()
+	return client
+}
+
+// TestWriteText tests frames are written with the payload length encoding for their size.
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		size   int
+		header []byte
+	}{
+		{0, []byte{0x81, 0}},
+		{125, []byte{0x81, 125}},
+		{126, []byte{0x81, 126, 0, 126}},
+		{0xffff, []byte{0x81, 126, 0xff, 0xff}},
+		{0x10000, []byte{0x81, 127, 0, 0, 0, 0, 0, 1, 0, 0}},
+	}
+
+	for _, tc := range tests {
+		msg := strings.Repeat("a", tc.size)
+		conn := pipe(func(conn net.Conn) {
+			writeText(conn, msg)
+		})
+		frame, _ := io.ReadAll(conn)
+		conn.Close()
+		if !bytes.HasPrefix(frame, tc.header) || string(frame[len(tc.header):]) != msg {
+			t.Errorf("livereload: wrong frame for %d bytes got header:%v want:%v", tc.size, frame[:min(len(frame), len(tc.header))], tc.header)
+		}
+
+		// Frames are read back by discardFrames up to the close frame
+		conn = pipe(func(conn net.Conn) {
+			writeText(conn, msg)
+			writeText(conn, "reload")
+			conn.Write([]byte{0x88, 0})
+			conn.Write([]byte("after"))
+		})
+		r := bufio.NewReader(conn)
+		discardFrames(r)
+		if rest, _ := io.ReadAll(r); string(rest) != "after" {
+			t.Errorf("livereload: frames of %d bytes not read back got:%q", tc.size, rest)
+		}
+		conn.Close()
+	}
+}
@@ -1,18 +1,64 @@
 package logrequest
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/middleware/wrap"
 )
 
 // TargetResponseTime sets the threshold for colorisation of response times
 var TargetResponseTime = 1 * time.Second
 
+// NoColor turns off colorisation, by default it is set on startup if the
+// NO_COLOR environment variable is set. Lines are only colorised if Output
+// is a terminal, lines sent to the log package are never colorised,
+// as its loggers may write to files.
+var NoColor = os.Getenv("NO_COLOR") != ""
+
+// Output is the writer request logs are written to, one per line.
+// If nil they are sent to the loggers in the log package.
+var Output io.Writer
+
+// Leveled sends request logs to the log package at a level set by
+// the status code (error for 5xx, warn for 4xx, info otherwise)
+// rather than with log.Printf, so they may be filtered by level.
+var Leveled = false
+
+// Error classes recorded for requests which did not succeed
+const (
+	// ClassAborted is a request cancelled by the client before a response was sent
+	ClassAborted = "aborted"
+	// ClassTimeout is a request which exceeded its deadline
+	ClassTimeout = "timeout"
+	// ClassHandlerError is a request for which the handler returned an error with a 5xx status
+	ClassHandlerError = "handler_error"
+	// ClassServerError is a 5xx response without a handler error
+	ClassServerError = "server_error"
+	// ClassClientError is a 4xx response
+	ClassClientError = "client_error"
+)
+
+// statusClientClosedRequest is the non-standard status used by the mux for aborted requests
+const statusClientClosedRequest = 499
+
+// outputMu serializes writes to Output, and protects the terminal check below
+var outputMu sync.Mutex
+
+// checkedOutput is the last Output checked by isTerminal, and terminal the result
+var (
+	checkedOutput io.Writer
+	terminal      bool
+)
+
 // hostname is set on startup to the current host
 var hostname string
 
@@ -38,16 +84,21 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 
 		// Wrap the response writer to record code
 		// Ideally we'd instead take mux.HandlerFunc
-		cw := newCodeResponseWriter(w)
+		rec := wrap.NewRecorder(w)
+
+		// Add log fields so that the mux can record handler errors
+		r = log.WithFields(r)
 
-		// Run the handler with our recording response writer
-		h(cw, r)
+		// Run the handler with our recording response writer,
+		// preserving Flusher, Hijacker and Pusher if w supports them
+		h(wrap.Wrap(w, rec), r)
 
 		// Calculate method, url, code, response time
 		method := r.Method
 		url := r.URL.Path
 		duration := time.Now().UTC().Sub(start)
-		code := cw.StatusCode
+		code := rec.Status
+		class := Classify(r, code)
 
 		// Skip logging assets, favicon
 		if strings.HasPrefix(url, "/assets") || strings.HasPrefix(url, "/favicon.ico") {
@@ -55,7 +106,7 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Pretty print to the standard loggers colorized
-		logWithColor(method, url, code, duration)
+		logWithColor(method, url, code, duration, class)
 
 		// Log the values to any value loggers (for export to monitoring services)
 		values := map[string]interface{}{
@@ -67,6 +118,12 @@ func Middleware(h http.HandlerFunc) http.HandlerFunc {
 			"bot":          isBot(r),
 			"duration":     duration.Nanoseconds(), // Store duration in nanoseconds in the db
 		}
+		if class != "" {
+			values["error_class"] = class
+		}
+		if err, ok := log.GetField(r, log.FieldError).(error); ok {
+			values["error"] = err.Error()
+		}
 		log.Values(values)
 	}
 
@@ -85,16 +142,20 @@ func MiddlewarePrint(h http.HandlerFunc) http.HandlerFunc {
 
 		// Wrap the response writer to record code
 		// Ideally we'd instead take mux.HandlerFunc
-		cw := newCodeResponseWriter(w)
+		rec := wrap.NewRecorder(w)
+
+		// Add log fields so that the mux can record handler errors
+		r = log.WithFields(r)
 
-		// Run the handler with our recording response writer
-		h(cw, r)
+		// Run the handler with our recording response writer,
+		// preserving Flusher, Hijacker and Pusher if w supports them
+		h(wrap.Wrap(w, rec), r)
 
 		// Calculate method, url, code, response time
 		method := r.Method
 		url := r.URL.Path
 		duration := time.Now().UTC().Sub(start)
-		code := cw.StatusCode
+		code := rec.Status
 
 		// Skip logging assets, favicon
 		if strings.HasPrefix(url, "/assets") || strings.HasPrefix(url, "/favicon.ico") {
@@ -102,8 +163,29 @@ func MiddlewarePrint(h http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Pretty print to the standard loggers colorized
-		logWithColor(method, url, code, duration)
+		logWithColor(method, url, code, duration, Classify(r, code))
+	}
+}
+
+// Classify returns the error class for a request which has been handled with
+// the status code given, or "" if it succeeded. Requests are classified by their
+// context (aborted or timed out), then the error recorded in the request log
+// fields by the mux, then the status code.
+func Classify(r *http.Request, code int) string {
+	ctxErr := r.Context().Err()
+	switch {
+	case errors.Is(ctxErr, context.Canceled) || code == statusClientClosedRequest:
+		return ClassAborted
+	case errors.Is(ctxErr, context.DeadlineExceeded) || code == http.StatusGatewayTimeout:
+		return ClassTimeout
+	case code >= 500 && log.GetField(r, log.FieldError) != nil:
+		return ClassHandlerError
+	case code >= 500:
+		return ClassServerError
+	case code >= 400:
+		return ClassClientError
 	}
+	return ""
 }
 
 // isBot returns true if it thinks this request came from a bot
@@ -117,30 +199,55 @@ func isBot(r *http.Request) bool {
 	return false
 }
 
-// codeResponseWriter defines a responseWriter which stores the status code
-type codeResponseWriter struct {
-	http.ResponseWriter
-	StatusCode int
+// isTerminal returns true if f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
-// WriteHeader stores the code before writing
-func (cw *codeResponseWriter) WriteHeader(code int) {
-	cw.StatusCode = code
-	cw.ResponseWriter.WriteHeader(code)
+// colorOutput returns true if lines written to w should be colorised,
+// it must be called with outputMu held.
+func colorOutput(w io.Writer) bool {
+	if NoColor {
+		return false
+	}
+	if w != checkedOutput {
+		f, ok := w.(*os.File)
+		checkedOutput, terminal = w, ok && isTerminal(f)
+	}
+	return terminal
 }
 
-// newCodeResponseWriter initialises a codeResponseWriter
-func newCodeResponseWriter(w http.ResponseWriter) *codeResponseWriter {
-	return &codeResponseWriter{w, http.StatusOK}
+// Format a string by wrapping in a given color code, if color is true
+func applyColor(color bool, f, s string) string {
+	if !color {
+		return s
+	}
+	return f + s + log.ColorNone
 }
 
-// Format a string by wrapping in a given color code
-func applyColor(f, s string) string {
-	return f + s + log.ColorNone
+// logWithColor logs the request to Output (colorised if it is a terminal),
+// or to the log package without color
+func logWithColor(method string, url string, code int, duration time.Duration, class string) {
+	switch {
+	case Output != nil:
+		outputMu.Lock()
+		format := lineFormat(colorOutput(Output), method, code, duration, class)
+		fmt.Fprintf(Output, format+"\n", method, url, code, duration)
+		outputMu.Unlock()
+	case Leveled:
+		log.Logf(codeLevel(code), lineFormat(false, method, code, duration, class), method, url, code, duration)
+	default:
+		log.Printf(lineFormat(false, method, code, duration, class), method, url, code, duration)
+	}
 }
 
-// logWithColor formats the log string with color depending on the arguments
-func logWithColor(method string, url string, code int, duration time.Duration) {
+// lineFormat returns the format for a request log line, with color depending
+// on the arguments if color is true, and the error class if there is one
+func lineFormat(color bool, method string, code int, duration time.Duration, class string) string {
 
 	// Start with all green, colorise output depending on values
 	m := log.ColorGreen
@@ -171,8 +278,20 @@ func logWithColor(method string, url string, code int, duration time.Duration) {
 
 	// Generate a format string using colors to wrap formats for values
 	// The equivalent of the plain format "%s %s -> %d in %s"
-	format := fmt.Sprintf("%s %%s %s %s in %s", applyColor(m, "%s"), applyColor(log.ColorCyan, "->"), applyColor(c, "%d"), applyColor(d, "%s"))
+	format := fmt.Sprintf("%s %%s %s %s in %s", applyColor(color, m, "%s"), applyColor(color, log.ColorCyan, "->"), applyColor(color, c, "%d"), applyColor(color, d, "%s"))
+	if class != "" {
+		format += " " + applyColor(color, c, class)
+	}
+	return format
+}
 
-	// Print to the log with this colorised format
-	log.Printf(format, method, url, code, duration)
+// codeLevel returns the log level for a response status code
+func codeLevel(code int) log.Level {
+	switch {
+	case code >= 500:
+		return log.LevelError
+	case code >= 400:
+		return log.LevelWarn
+	}
+	return log.LevelInfo
 }
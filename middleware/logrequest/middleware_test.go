@@ -0,0 +1,251 @@
+package logrequest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// captureLogger records lines sent to the log package
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// Printf records the line
+func (c *captureLogger) Printf(format string, args ...interface{}) {
+	c.mu.Lock()
+	c.lines = append(c.lines, fmt.Sprintf(format, args...))
+	c.mu.Unlock()
+}
+
+// take returns the lines recorded and resets them
+func (c *captureLogger) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lines := c.lines
+	c.lines = nil
+	return lines
+}
+
+// captured records lines logged in tests
+var captured = &captureLogger{}
+
+func TestMain(m *testing.M) {
+	log.Add(captured)
+	os.Exit(m.Run())
+}
+
+// serve serves a request for path with the middleware, responding with code
+func serve(method, path string, code int) {
+	h := Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	})
+	h(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+// setConfig sets the package config for a test with no Output, and restores it after the test
+func setConfig(t *testing.T, leveled, noColor bool) {
+	oldOutput, oldLeveled, oldNoColor := Output, Leveled, NoColor
+	t.Cleanup(func() {
+		Output, Leveled, NoColor = oldOutput, oldLeveled, oldNoColor
+		captured.take()
+	})
+	Output, Leveled, NoColor = nil, leveled, noColor
+	captured.take()
+}
+
+// TestOutput tests lines are written to Output, without color unless it is a terminal.
+func TestOutput(t *testing.T) {
+	setConfig(t, false, false)
+	var buf bytes.Buffer
+	Output = &buf
+
+	serve(http.MethodPost, "/users", http.StatusFound)
+	line := buf.String()
+	if !strings.HasPrefix(line, "POST /users -> 302 in ") || !strings.HasSuffix(line, "\n") {
+		t.Errorf("logrequest: wrong output got:%q", line)
+	}
+	if strings.Contains(line, "\033[") {
+		t.Errorf("logrequest: colored output to buffer got:%q", line)
+	}
+	if lines := captured.take(); len(lines) != 0 {
+		t.Errorf("logrequest: lines sent to log with Output set got:%q", lines)
+	}
+
+	// Files are not terminals, so are not colored
+	f, err := os.Create(t.TempDir() + "/requests.log")
+	if err != nil {
+		t.Fatalf("logrequest: error creating file %s", err)
+	}
+	defer f.Close()
+	Output = f
+	serve(http.MethodGet, "/", http.StatusOK)
+	data, _ := os.ReadFile(f.Name())
+	if !strings.HasPrefix(string(data), "GET / -> 200 in ") {
+		t.Errorf("logrequest: wrong file output got:%q", data)
+	}
+}
+
+// TestNoColor tests terminals are colored unless NoColor is set.
+func TestNoColor(t *testing.T) {
+	// Character devices are treated as terminals
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("logrequest: no %s", os.DevNull)
+	}
+	defer tty.Close()
+
+	setConfig(t, false, false)
+	outputMu.Lock()
+	color := colorOutput(tty)
+	outputMu.Unlock()
+	if !color {
+		t.Errorf("logrequest: terminal output not colored")
+	}
+	if format := lineFormat(color, http.MethodGet, http.StatusOK, 0, ""); !strings.Contains(format, log.ColorGreen) {
+		t.Errorf("logrequest: wrong colored format got:%q", format)
+	}
+
+	NoColor = true
+	outputMu.Lock()
+	color = colorOutput(tty)
+	outputMu.Unlock()
+	if color {
+		t.Errorf("logrequest: terminal output colored with NoColor set")
+	}
+}
+
+// TestLog tests lines are sent to the log package without color.
+func TestLog(t *testing.T) {
+	setConfig(t, false, false)
+
+	serve(http.MethodGet, "/missing", http.StatusNotFound)
+	lines := captured.take()
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "GET /missing -> 404 in ") || !strings.HasSuffix(lines[0], " client_error") {
+		t.Errorf("logrequest: wrong log lines got:%q", lines)
+	}
+	if len(lines) == 1 && strings.Contains(lines[0], "\033[") {
+		t.Errorf("logrequest: colored line sent to log got:%q", lines[0])
+	}
+
+	// Assets are not logged
+	serve(http.MethodGet, "/assets/app.css", http.StatusOK)
+	if lines := captured.take(); len(lines) != 0 {
+		t.Errorf("logrequest: asset logged got:%q", lines)
+	}
+}
+
+// TestLeveled tests lines are logged at a level set by the status code.
+func TestLeveled(t *testing.T) {
+	setConfig(t, true, false)
+
+	tests := []struct {
+		code int
+		want string
+	}{
+		{http.StatusOK, "INFO GET / -> 200 in "},
+		{http.StatusNotFound, "WARN GET / -> 404 in "},
+		{http.StatusInternalServerError, "ERROR GET / -> 500 in "},
+	}
+	for _, tc := range tests {
+		serve(http.MethodGet, "/", tc.code)
+		lines := captured.take()
+		if len(lines) != 1 || !strings.HasPrefix(lines[0], tc.want) || strings.Contains(lines[0], "\033[") {
+			t.Errorf("logrequest: wrong leveled lines for %d got:%q want:%s", tc.code, lines, tc.want)
+		}
+	}
+
+	// Lines below the package threshold are discarded
+	log.SetLevel(log.LevelWarn)
+	defer log.SetLevel(log.LevelInfo)
+	serve(http.MethodGet, "/", http.StatusOK)
+	if lines := captured.take(); len(lines) != 0 {
+		t.Errorf("logrequest: line below threshold logged got:%q", lines)
+	}
+}
+
+// valuesLogger records values sent to the log package
+type valuesLogger struct {
+	values []map[string]interface{}
+}
+
+func (v *valuesLogger) Values(values map[string]interface{}) {
+	v.values = append(v.values, values)
+}
+
+func (v *valuesLogger) ValuesBatch(values []map[string]interface{}) {
+	v.values = append(v.values, values...)
+}
+
+// TestClassify tests requests are classified by context, handler error and status.
+func TestClassify(t *testing.T) {
+	setConfig(t, false, false)
+	vl := &valuesLogger{}
+	log.AddValuesLogger(vl, log.SeriesFilter("requests"))
+	defer log.RemoveValuesLogger(vl)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code int
+		err  error
+		want string
+	}{
+		{"ok", context.Background(), http.StatusOK, nil, ""},
+		{"redirect", context.Background(), http.StatusFound, nil, ""},
+		{"cancelled", cancelled, http.StatusOK, nil, ClassAborted},
+		{"client closed", context.Background(), statusClientClosedRequest, nil, ClassAborted},
+		{"deadline", expired, http.StatusGatewayTimeout, nil, ClassTimeout},
+		{"gateway timeout", context.Background(), http.StatusGatewayTimeout, nil, ClassTimeout},
+		{"handler error", context.Background(), http.StatusInternalServerError, errors.New("failed"), ClassHandlerError},
+		{"server error", context.Background(), http.StatusBadGateway, nil, ClassServerError},
+		{"client error", context.Background(), http.StatusNotFound, nil, ClassClientError},
+		{"client error with handler error", context.Background(), http.StatusBadRequest, errors.New("invalid"), ClassClientError},
+	}
+
+	for _, tc := range tests {
+		h := Middleware(func(w http.ResponseWriter, r *http.Request) {
+			if tc.err != nil {
+				log.SetField(r, log.FieldError, tc.err)
+			}
+			w.WriteHeader(tc.code)
+		})
+		vl.values = nil
+		h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(tc.ctx))
+
+		if len(vl.values) != 1 {
+			t.Errorf("logrequest: %s wrong values got:%v", tc.name, vl.values)
+			continue
+		}
+		class, ok := vl.values[0]["error_class"]
+		if tc.want == "" && ok {
+			t.Errorf("logrequest: %s unexpected error class got:%v", tc.name, class)
+		} else if tc.want != "" && class != tc.want {
+			t.Errorf("logrequest: %s wrong error class got:%v want:%s", tc.name, class, tc.want)
+		}
+		if tc.err != nil && vl.values[0]["error"] != tc.err.Error() {
+			t.Errorf("logrequest: %s wrong error got:%v want:%s", tc.name, vl.values[0]["error"], tc.err)
+		}
+
+		lines := captured.take()
+		if len(lines) != 1 || (tc.want != "" && !strings.HasSuffix(lines[0], " "+tc.want)) {
+			t.Errorf("logrequest: %s wrong log lines got:%q", tc.name, lines)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package quota limits the requests each authenticated identity (a user or
+// api key) may make in a window, with limits set per tier, and reports usage
+// with X-RateLimit-* headers.
+package quota
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/store"
+)
+
+// Usage
+// q := quota.New(quota.Config{
+// 	Tiers: map[string]quota.Limit{
+// 		"":    {Requests: 1000, Window: time.Hour},
+// 		"pro": {Requests: 10000, Window: time.Hour},
+// 	},
+// 	Tier: func(r *http.Request) string { return currentUser(r).Plan },
+// })
+// m.AddMiddleware(q.Middleware)
+
+// Headers reporting usage to clients
+const (
+	HeaderLimit     = "X-RateLimit-Limit"
+	HeaderRemaining = "X-RateLimit-Remaining"
+	HeaderReset     = "X-RateLimit-Reset"
+)
+
+// Limit is the number of requests allowed in each window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Config represents the config for a Quota
+type Config struct {
+	// Identity returns the identity requests are counted against, default
+	// the value stored under mux.KeyUser formatted with fmt.Sprint.
+	// Requests without an identity are not limited.
+	Identity func(*http.Request) string
+
+	// Tier returns the name of the tier for a request, default "".
+	Tier func(*http.Request) string
+
+	// Tiers sets the limit for each tier, the tier "" is used for tiers
+	// without a limit. If no limit is found requests are not limited.
+	Tiers map[string]Limit
+
+	// Store counts requests, default a memory store
+	Store store.Store
+
+	// Prefix is prepended to keys in the store, default "quota:"
+	Prefix string
+}
+
+// Quota limits requests by identity.
+type Quota struct {
+	config Config
+}
+
+// New returns a new Quota with the config given.
+func New(config Config) *Quota {
+	// Set defaults if none set
+	if config.Identity == nil {
+		config.Identity = userIdentity
+	}
+	if config.Tier == nil {
+		config.Tier = func(*http.Request) string { return "" }
+	}
+	if config.Store == nil {
+		config.Store = store.NewMemoryStore(0)
+	}
+	if config.Prefix == "" {
+		config.Prefix = "quota:"
+	}
+	return &Quota{config: config}
+}
+
+// Middleware counts requests against the quota for their identity, and
+// rejects requests over quota with 429 Too Many Requests.
+// It must run after the middleware which authenticates the request.
+func (q *Quota) Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := q.config.Identity(r)
+		if id == "" {
+			h(w, r)
+			return
+		}
+
+		tier := q.config.Tier(r)
+		limit, ok := q.config.Tiers[tier]
+		if !ok {
+			limit, ok = q.config.Tiers[""]
+		}
+		if !ok || limit.Requests <= 0 || limit.Window <= 0 {
+			h(w, r)
+			return
+		}
+
+		count, reset, err := q.count(tier, id, limit.Window)
+		if err != nil {
+			// Fail open rather than rejecting requests if the store is unavailable
+			log.Errorf("quota: error counting request:%s", err)
+			h(w, r)
+			return
+		}
+
+		remaining := limit.Requests - int(count)
+		if remaining < 0 {
+			remaining = 0
+		}
+		w.Header().Set(HeaderLimit, strconv.Itoa(limit.Requests))
+		w.Header().Set(HeaderRemaining, strconv.Itoa(remaining))
+		w.Header().Set(HeaderReset, strconv.FormatInt(reset.Unix(), 10))
+
+		if count > int64(limit.Requests) {
+			wait := int(time.Until(reset).Seconds() + 0.5)
+			if wait < 1 {
+				wait = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(wait))
+			http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// count increments the count for the identity in the current window, and returns
+// the count and the end of the window. Windows are aligned to multiples of their
+// duration, so that counts are shared by servers using the same store.
+func (q *Quota) count(tier, id string, window time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	start := now.Truncate(window)
+	key := q.config.Prefix + tier + ":" + id + ":" + strconv.FormatInt(start.Unix(), 10)
+
+	count, err := q.config.Store.Incr(key)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if count == 1 {
+		err = q.config.Store.Expire(key, window)
+	}
+	return count, start.Add(window), err
+}
+
+// userIdentity returns the user stored on the request formatted with fmt.Sprint
+func userIdentity(r *http.Request) string {
+	user, ok := mux.Value[interface{}](r, mux.KeyUser)
+	if !ok || user == nil {
+		return ""
+	}
+	return fmt.Sprint(user)
+}
@@ -0,0 +1,128 @@
+package quota
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/store"
+)
+
+// brokenStore is a memory store which fails to count requests
+type brokenStore struct {
+	*store.MemoryStore
+}
+
+func (s brokenStore) Incr(key string) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+// serve serves n requests for the identity and tier with the quota, and returns the last response
+func serve(q *Quota, id, tier string, n int) (*httptest.ResponseRecorder, int) {
+	served := 0
+	h := q.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	})
+	var w *httptest.ResponseRecorder
+	for i := 0; i < n; i++ {
+		w = httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Identity", id)
+		r.Header.Set("X-Tier", tier)
+		h(w, r)
+	}
+	return w, served
+}
+
+// newQuota returns a quota reading the identity and tier from headers
+func newQuota(s store.Store) *Quota {
+	return New(Config{
+		Identity: func(r *http.Request) string { return r.Header.Get("X-Identity") },
+		Tier:     func(r *http.Request) string { return r.Header.Get("X-Tier") },
+		Tiers: map[string]Limit{
+			"":    {Requests: 2, Window: 24 * time.Hour},
+			"pro": {Requests: 5, Window: 24 * time.Hour},
+		},
+		Store: s,
+	})
+}
+
+// TestQuota tests usage headers are set, and requests over quota rejected.
+func TestQuota(t *testing.T) {
+	q := newQuota(nil)
+	reset := strconv.FormatInt(time.Now().Truncate(24*time.Hour).Add(24*time.Hour).Unix(), 10)
+
+	w, served := serve(q, "alice", "", 1)
+	if served != 1 || w.Header().Get(HeaderLimit) != "2" || w.Header().Get(HeaderRemaining) != "1" || w.Header().Get(HeaderReset) != reset {
+		t.Errorf("quota: wrong headers got:%v", w.Header())
+	}
+
+	w, served = serve(q, "alice", "", 2)
+	if served != 1 || w.Code != http.StatusTooManyRequests || w.Header().Get(HeaderRemaining) != "0" {
+		t.Errorf("quota: request over quota not rejected got:%d %d %v", served, w.Code, w.Header())
+	}
+	if wait, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || wait < 1 || wait > 24*60*60 {
+		t.Errorf("quota: wrong Retry-After got:%s", w.Header().Get("Retry-After"))
+	}
+
+	// Other identities have their own quota
+	if _, served := serve(q, "bob", "", 2); served != 2 {
+		t.Errorf("quota: wrong requests served for bob got:%d want:2", served)
+	}
+}
+
+// TestQuotaTiers tests limits are set by tier, falling back to the "" tier.
+func TestQuotaTiers(t *testing.T) {
+	q := newQuota(nil)
+
+	tests := []struct {
+		id, tier string
+		served   int
+		limit    string
+	}{
+		{"alice", "pro", 5, "5"},
+		{"bob", "free", 2, "2"},
+		{"carol", "", 2, "2"},
+	}
+	for _, tc := range tests {
+		w, served := serve(q, tc.id, tc.tier, 6)
+		if served != tc.served || w.Header().Get(HeaderLimit) != tc.limit {
+			t.Errorf("quota: wrong limit for tier %q got:%d %s want:%d %s", tc.tier, served, w.Header().Get(HeaderLimit), tc.served, tc.limit)
+		}
+	}
+
+	// Without a "" tier, requests for other tiers are not limited
+	q = New(Config{
+		Identity: func(r *http.Request) string { return r.Header.Get("X-Identity") },
+		Tier:     func(r *http.Request) string { return r.Header.Get("X-Tier") },
+		Tiers:    map[string]Limit{"pro": {Requests: 1, Window: time.Hour}},
+	})
+	if w, served := serve(q, "alice", "free", 3); served != 3 || w.Header().Get(HeaderLimit) != "" {
+		t.Errorf("quota: tier without limit was limited got:%d %v", served, w.Header())
+	}
+}
+
+// TestQuotaNoIdentity tests requests without an identity are not limited.
+func TestQuotaNoIdentity(t *testing.T) {
+	w, served := serve(newQuota(nil), "", "", 5)
+	if served != 5 || w.Header().Get(HeaderLimit) != "" {
+		t.Errorf("quota: anonymous requests limited got:%d %v", served, w.Header())
+	}
+
+	// The default identity is the user stored on the request
+	q := New(Config{Tiers: map[string]Limit{"": {Requests: 1, Window: time.Hour}}})
+	if _, served := serve(q, "alice", "", 3); served != 3 {
+		t.Errorf("quota: requests without a user limited got:%d", served)
+	}
+}
+
+// TestQuotaStoreError tests requests are served if the store fails.
+func TestQuotaStoreError(t *testing.T) {
+	w, served := serve(newQuota(brokenStore{store.NewMemoryStore(0)}), "alice", "", 5)
+	if served != 5 || w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "" {
+		t.Errorf("quota: requests not served with failing store got:%d %d %v", served, w.Code, w.Header())
+	}
+}
@@ -0,0 +1,92 @@
+// Package realip sets the request RemoteAddr to the client ip address
+// given in forwarding headers, for requests received from trusted proxies.
+package realip
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Usage
+// realip.TrustedProxies = []string{"10.0.0.0/8"}
+// m.AddMiddleware(realip.Middleware)
+
+// These package level variables should be set if required before the middleware is added
+
+// TrustedProxies lists the ip ranges (in CIDR notation) of proxies whose
+// forwarding headers are trusted, by default loopback and private ranges.
+var TrustedProxies = []string{"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+// Headers lists the headers read for the client ip in priority order.
+// Addresses in X-Forwarded-For are read from the right, skipping trusted proxies.
+var Headers = []string{"X-Forwarded-For", "X-Real-Ip"}
+
+// Middleware sets r.RemoteAddr to the client ip if the request was sent
+// by a trusted proxy, so that handlers and loggers see the client address.
+func Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if trusted(IP(r)) {
+			if ip := clientIP(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+		}
+		h(w, r)
+	}
+}
+
+// IP returns the ip address from r.RemoteAddr, which may or may not include a port.
+func IP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientIP returns the client ip from the forwarding headers, or "" if none is valid
+func clientIP(r *http.Request) string {
+	for _, header := range Headers {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+
+		// Read X-Forwarded-For from the right, as proxies append to it
+		if strings.EqualFold(header, "X-Forwarded-For") {
+			ips := strings.Split(strings.Join(values, ","), ",")
+			for i := len(ips) - 1; i >= 0; i-- {
+				ip := strings.TrimSpace(ips[i])
+				if net.ParseIP(ip) == nil {
+					break
+				}
+				if i == 0 || !trusted(ip) {
+					return ip
+				}
+			}
+			continue
+		}
+
+		ip := strings.TrimSpace(values[0])
+		if net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+	return ""
+}
+
+// trusted returns true if ip is in one of the TrustedProxies ranges
+func trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, cidr := range TrustedProxies {
+		_, network, err := net.ParseCIDR(cidr)
+		if err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Package recovery recovers from panics in handlers, logging the panic with
+// its stack trace and responding with 500 Internal Server Error, so that a
+// panic in one handler does not drop the connection without a response.
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/middleware/wrap"
+)
+
+// Usage
+// m.AddMiddleware(recovery.Middleware)
+
+// Middleware recovers from panics in h, logs them with the stack trace,
+// and responds with 500 Internal Server Error if nothing has been written.
+// Panics with http.ErrAbortHandler are passed on to the server.
+func Middleware(h http.HandlerFunc) http.HandlerFunc {
+	return recoverer(h, false)
+}
+
+// DebugMiddleware is like Middleware, but also writes the panic and stack trace
+// to the response, it should only be used in development.
+func DebugMiddleware(h http.HandlerFunc) http.HandlerFunc {
+	return recoverer(h, true)
+}
+
+// recoverer returns a handler recovering from panics in h
+func recoverer(h http.HandlerFunc, debugResponse bool) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := wrap.NewRecorder(w)
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			stack := debug.Stack()
+			log.ForRequest(r).Errorf("recovery: panic serving %s %s: %v\n%s", r.Method, r.URL.Path, p, stack)
+
+			// If the handler has started the response, it cannot be replaced
+			if rec.Written() {
+				return
+			}
+			if debugResponse {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "panic: %v\n\n%s", p, stack)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+
+		h(wrap.Wrap(w, rec), r)
+	}
+}
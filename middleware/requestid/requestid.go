@@ -0,0 +1,69 @@
+// Package requestid assigns an id to each request for correlating log messages
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// Header is the header used to read and send request ids
+var Header = "X-Request-Id"
+
+// TrustHeader uses request ids sent by clients or proxies in Header if set,
+// this should only be enabled behind a proxy which sets the header.
+var TrustHeader = false
+
+// Middleware sets a request id on the response header and in the
+// request log fields, along with the request method, so that
+// log.ForRequest(r) includes them with every message.
+func Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := ""
+		if TrustHeader {
+			id = r.Header.Get(Header)
+		}
+		if !valid(id) {
+			id = newID()
+		}
+
+		w.Header().Set(Header, id)
+
+		// Store the id and method in the log fields for this request
+		r = log.WithFields(r)
+		log.SetField(r, log.FieldRequestID, id)
+		log.SetField(r, log.FieldMethod, r.Method)
+
+		h(w, r)
+	}
+}
+
+// Get returns the request id for this request, or "" if none is set.
+func Get(r *http.Request) string {
+	id, _ := log.GetField(r, log.FieldRequestID).(string)
+	return id
+}
+
+// newID returns a random 16 byte hex encoded id.
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// valid returns true if the id is of reasonable length and contains only
+// printable ascii characters, to avoid log injection by clients.
+func valid(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
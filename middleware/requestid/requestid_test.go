@@ -0,0 +1,77 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// serve serves a request with the incoming id (if any) and returns the id seen by the handler
+func serve(incoming string) (*httptest.ResponseRecorder, string, interface{}) {
+	var id string
+	var method interface{}
+	h := Middleware(func(w http.ResponseWriter, r *http.Request) {
+		id = Get(r)
+		method = log.GetField(r, log.FieldMethod)
+	})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if incoming != "" {
+		r.Header.Set(Header, incoming)
+	}
+	h(w, r)
+	return w, id, method
+}
+
+// TestMiddleware tests an id is generated for each request and set on the response.
+func TestMiddleware(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	w, id, method := serve("")
+	if !generated.MatchString(id) || w.Header().Get(Header) != id || method != http.MethodPost {
+		t.Errorf("requestid: wrong id got:%q header:%q method:%v", id, w.Header().Get(Header), method)
+	}
+
+	_, other, _ := serve("")
+	if other == id {
+		t.Errorf("requestid: id reused got:%s", id)
+	}
+
+	// Incoming ids are ignored unless trusted
+	if _, id, _ := serve("incoming"); !generated.MatchString(id) {
+		t.Errorf("requestid: untrusted id used got:%s", id)
+	}
+}
+
+// TestTrustHeader tests valid incoming ids are used when trusted.
+func TestTrustHeader(t *testing.T) {
+	defer func() { TrustHeader = false }()
+	TrustHeader = true
+
+	tests := []struct {
+		incoming string
+		used     bool
+	}{
+		{"abc-123", true},
+		{"Root=1-5759e988-bd862e3fe1be46a994272793", true},
+		{strings.Repeat("a", 128), true},
+		{strings.Repeat("a", 129), false},
+		{"two words", false},
+		{"line\nbreak", false},
+		{"café", false},
+	}
+
+	for _, tc := range tests {
+		w, id, _ := serve(tc.incoming)
+		if (id == tc.incoming) != tc.used {
+			t.Errorf("requestid: wrong id for %q got:%q", tc.incoming, id)
+		}
+		if w.Header().Get(Header) != id {
+			t.Errorf("requestid: wrong header for %q got:%q", tc.incoming, w.Header().Get(Header))
+		}
+	}
+}
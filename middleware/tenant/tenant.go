@@ -0,0 +1,210 @@
+// Package tenant resolves the tenant for each request from the host, a header
+// or the path prefix, stores it on the request, and optionally routes requests
+// with a separate mux for each tenant.
+package tenant
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/log"
+)
+
+// Usage
+// t := tenant.New(tenant.Config{Sources: []tenant.Source{tenant.Host}, Domain: "example.com", Lookup: findAccount})
+// t.Mount("acme", acmeMux) // optional
+// m.AddMiddleware(t.Middleware)
+// ...
+// account := tenant.FromRequest(r)
+
+// Source is a source of the tenant id
+type Source int
+
+// Sources of the tenant id
+const (
+	// Host reads the tenant from the subdomain of Domain e.g. acme.example.com,
+	// or the whole host if Domain is not set
+	Host Source = iota
+	// Header reads the tenant from the request header HeaderName
+	Header
+	// Path reads the tenant from the first path segment e.g. /acme/pages
+	Path
+)
+
+// FieldTenant is the request log field for the tenant id
+const FieldTenant = "tenant"
+
+// Tenant is the tenant resolved for a request.
+type Tenant struct {
+	// ID is the id read from the request
+	ID string
+	// Data is the value returned by Lookup, if any
+	Data interface{}
+}
+
+// Config represents the config for a Resolver
+type Config struct {
+	// Sources lists the sources of the tenant id in priority order, default Host
+	Sources []Source
+
+	// Domain is the parent domain for Host, tenants are read from subdomains of it
+	Domain string
+
+	// HeaderName is the header read for Header, default X-Tenant
+	HeaderName string
+
+	// StripPrefix removes the tenant prefix from the path before routing
+	// if the tenant was read from the path, so /acme/pages is routed as /pages.
+	StripPrefix bool
+
+	// Lookup loads data for a tenant id, returning nil data and a nil error if
+	// the tenant does not exist. Default accepts all ids without data.
+	Lookup func(id string) (interface{}, error)
+
+	// Required rejects requests without a tenant with 404 Not Found
+	Required bool
+}
+
+// Resolver resolves tenants and dispatches to their muxes.
+type Resolver struct {
+	config Config
+
+	mu    sync.RWMutex
+	muxes map[string]*mux.Mux
+}
+
+// New returns a new Resolver with the config given.
+func New(config Config) *Resolver {
+	// Set defaults if none set
+	if len(config.Sources) == 0 {
+		config.Sources = []Source{Host}
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-Tenant"
+	}
+	return &Resolver{
+		config: config,
+		muxes:  make(map[string]*mux.Mux),
+	}
+}
+
+// Mount sets a mux which serves all requests for the tenant with this id,
+// requests for other tenants continue down the middleware chain.
+func (t *Resolver) Mount(id string, m *mux.Mux) {
+	t.mu.Lock()
+	t.muxes[id] = m
+	t.mu.Unlock()
+}
+
+// Middleware resolves the tenant for the request and stores it under mux.KeyTenant.
+// If a mux is mounted for the tenant, it serves the request instead of the next handler.
+func (t *Resolver) Middleware(h http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Add a values store if the request is not served by a mux
+		r = mux.WithValues(r)
+
+		tenant, r, err := t.Resolve(r)
+		if err != nil {
+			log.Errorf("tenant: error looking up tenant:%s", err)
+			http.Error(w, "tenant lookup failed", http.StatusInternalServerError)
+			return
+		}
+		if tenant == nil {
+			if t.config.Required {
+				http.NotFound(w, r)
+				return
+			}
+			h(w, r)
+			return
+		}
+
+		mux.SetValue(r, mux.KeyTenant, tenant)
+		log.SetField(r, FieldTenant, tenant.ID)
+
+		t.mu.RLock()
+		tm := t.muxes[tenant.ID]
+		t.mu.RUnlock()
+		if tm != nil {
+			tm.ServeHTTP(w, r)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// Resolve returns the tenant for the request, or nil if there is none,
+// and the request with the tenant prefix stripped if StripPrefix is set.
+func (t *Resolver) Resolve(r *http.Request) (*Tenant, *http.Request, error) {
+	for _, source := range t.config.Sources {
+		id := ""
+		switch source {
+		case Host:
+			id = t.hostTenant(r.Host)
+		case Header:
+			id = r.Header.Get(t.config.HeaderName)
+		case Path:
+			id = pathTenant(r.URL.Path)
+		}
+		if id == "" {
+			continue
+		}
+
+		tenant := &Tenant{ID: id}
+		if t.config.Lookup != nil {
+			data, err := t.config.Lookup(id)
+			if err != nil {
+				return nil, r, err
+			}
+			if data == nil {
+				continue
+			}
+			tenant.Data = data
+		}
+
+		if source == Path && t.config.StripPrefix {
+			r = mux.StripPrefix(r, "/"+id)
+		}
+		return tenant, r, nil
+	}
+	return nil, r, nil
+}
+
+// FromRequest returns the tenant for this request, or nil if none is set.
+func FromRequest(r *http.Request) *Tenant {
+	tenant, _ := mux.Value[*Tenant](r, mux.KeyTenant)
+	return tenant
+}
+
+// hostTenant returns the subdomain of Domain in host, or the host if Domain is not set
+func (t *Resolver) hostTenant(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if t.config.Domain == "" {
+		return host
+	}
+	suffix := "." + strings.ToLower(t.config.Domain)
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	sub := strings.TrimSuffix(host, suffix)
+	if sub == "www" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// pathTenant returns the first segment of path
+func pathTenant(path string) string {
+	segment := strings.TrimPrefix(path, "/")
+	if i := strings.IndexByte(segment, '/'); i >= 0 {
+		segment = segment[:i]
+	}
+	return segment
+}
@@ -0,0 +1,147 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fragmenta/mux"
+)
+
+// serve serves a request with the resolver middleware outside a mux, and
+// returns the response and the tenant and path seen by the next handler.
+func serve(t *Resolver, r *http.Request) (*httptest.ResponseRecorder, *Tenant, string) {
+	var tenant *Tenant
+	path := ""
+	h := t.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		tenant = FromRequest(r)
+		path = r.URL.Path
+	})
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w, tenant, path
+}
+
+// TestSources tests tenants are read from each source in priority order.
+func TestSources(t *testing.T) {
+	tests := []struct {
+		config Config
+		host   string
+		header string
+		path   string
+		want   string
+		route  string
+	}{
+		{Config{Domain: "example.com"}, "acme.example.com", "", "/pages", "acme", "/pages"},
+		{Config{Domain: "example.com"}, "ACME.example.com:8080", "", "/pages", "acme", "/pages"},
+		{Config{Domain: "example.com"}, "www.example.com", "", "/pages", "", "/pages"},
+		{Config{Domain: "example.com"}, "a.b.example.com", "", "/pages", "", "/pages"},
+		{Config{Domain: "example.com"}, "example.org", "", "/pages", "", "/pages"},
+		{Config{}, "acme.org", "", "/pages", "acme.org", "/pages"},
+		{Config{Sources: []Source{Header}}, "acme.example.com", "globex", "/pages", "globex", "/pages"},
+		{Config{Sources: []Source{Header}, HeaderName: "X-Account"}, "", "", "/pages", "", "/pages"},
+		{Config{Sources: []Source{Path}}, "", "", "/acme/pages", "acme", "/acme/pages"},
+		{Config{Sources: []Source{Path}, StripPrefix: true}, "", "", "/acme/pages", "acme", "/pages"},
+		{Config{Sources: []Source{Path}, StripPrefix: true}, "", "", "/acme", "acme", "/"},
+		{Config{Sources: []Source{Header, Path}, StripPrefix: true}, "", "globex", "/acme/pages", "globex", "/acme/pages"},
+		{Config{Sources: []Source{Header, Path}}, "", "", "/acme/pages", "acme", "/acme/pages"},
+	}
+
+	for _, tc := range tests {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		r.Host = tc.host
+		if tc.header != "" {
+			r.Header.Set("X-Tenant", tc.header)
+		}
+		_, tenant, path := serve(New(tc.config), r)
+
+		id := ""
+		if tenant != nil {
+			id = tenant.ID
+		}
+		if id != tc.want || path != tc.route {
+			t.Errorf("tenant: wrong tenant for %s %s %s got:%q %s want:%q %s", tc.host, tc.header, tc.path, id, path, tc.want, tc.route)
+		}
+	}
+}
+
+// TestLookup tests unknown tenants are skipped, and lookup errors rejected.
+func TestLookup(t *testing.T) {
+	lookup := func(id string) (interface{}, error) {
+		switch id {
+		case "acme":
+			return "Acme Corp", nil
+		case "broken":
+			return nil, errors.New("database unavailable")
+		}
+		return nil, nil
+	}
+	resolver := New(Config{Sources: []Source{Header, Path}, Lookup: lookup})
+
+	r := httptest.NewRequest(http.MethodGet, "/acme/pages", nil)
+	r.Header.Set("X-Tenant", "unknown")
+	_, tenant, _ := serve(resolver, r)
+	if tenant == nil || tenant.ID != "acme" || tenant.Data != "Acme Corp" {
+		t.Errorf("tenant: wrong tenant after lookup got:%+v", tenant)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/broken", nil)
+	w, tenant, _ := serve(resolver, r)
+	if w.Code != http.StatusInternalServerError || tenant != nil {
+		t.Errorf("tenant: wrong response for lookup error got:%d %+v", w.Code, tenant)
+	}
+}
+
+// TestRequired tests requests without a tenant are rejected if Required is set.
+func TestRequired(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/pages", nil)
+	r.Host = "example.com"
+
+	w, _, path := serve(New(Config{Domain: "example.com", Required: true}), r)
+	if w.Code != http.StatusNotFound || path != "" {
+		t.Errorf("tenant: wrong response without required tenant got:%d %q", w.Code, path)
+	}
+
+	w, _, path = serve(New(Config{Domain: "example.com"}), r)
+	if w.Code != http.StatusOK || path != "/pages" {
+		t.Errorf("tenant: wrong response without optional tenant got:%d %q", w.Code, path)
+	}
+}
+
+// TestMount tests requests for tenants with a mux are served by it.
+func TestMount(t *testing.T) {
+	resolver := New(Config{Sources: []Source{Path}, StripPrefix: true})
+
+	acme := mux.New()
+	var tenant *Tenant
+	acme.Get("/pages", func(w http.ResponseWriter, r *http.Request) error {
+		tenant = FromRequest(r)
+		w.Write([]byte("acme pages"))
+		return nil
+	})
+	resolver.Mount("acme", acme)
+
+	m := mux.New()
+	m.AddMiddleware(resolver.Middleware)
+	m.Get("/{tenant}/pages", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("default pages"))
+		return nil
+	})
+	m.Get("/pages", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("stripped pages"))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/acme/pages", nil))
+	if w.Body.String() != "acme pages" || tenant == nil || tenant.ID != "acme" {
+		t.Errorf("tenant: wrong mounted response got:%q %+v", w.Body.String(), tenant)
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/globex/pages", nil))
+	if w.Body.String() != "stripped pages" {
+		t.Errorf("tenant: wrong response for tenant without mux got:%q", w.Body.String())
+	}
+}
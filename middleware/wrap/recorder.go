@@ -0,0 +1,51 @@
+package wrap
+
+import (
+	"net/http"
+)
+
+// Recorder records the status and size of the response written to the
+// ResponseWriter it wraps. Informational (1xx) responses such as
+// 103 Early Hints are passed through but not recorded, as they
+// are followed by the final response.
+//
+//	rec := wrap.NewRecorder(w)
+//	h(wrap.Wrap(w, rec), r)
+//	log.Printf("status:%d size:%d", rec.Status, rec.Size)
+type Recorder struct {
+	http.ResponseWriter
+
+	// Status is the final status written, or 200 if none was written explicitly
+	Status int
+	// Size is the number of bytes of body written
+	Size int64
+
+	wroteHeader bool
+}
+
+// NewRecorder returns a Recorder wrapping w.
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+// WriteHeader records the first final status before writing
+func (r *Recorder) WriteHeader(code int) {
+	if code >= 200 && !r.wroteHeader {
+		r.Status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write records an implicit 200 status and the size before writing
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.Size += int64(n)
+	return n, err
+}
+
+// Written returns true if the final status or any of the body has been written
+func (r *Recorder) Written() bool {
+	return r.wroteHeader
+}
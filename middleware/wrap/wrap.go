@@ -0,0 +1,117 @@
+// Package wrap wraps http.ResponseWriters used by middleware, while preserving
+// the optional http.Flusher, http.Hijacker and http.Pusher interfaces of the
+// underlying writer, so that streaming and websockets work behind middleware.
+package wrap
+
+import (
+	"net/http"
+)
+
+// Wrap returns a ResponseWriter which calls w for Header, Write and WriteHeader,
+// and exposes http.Flusher, http.Hijacker and http.Pusher only if underlying
+// supports them. If w implements one of these interfaces itself, its
+// implementation is used, so that writers which buffer output can flush it.
+// The returned writer also supports http.ResponseController via Unwrap.
+func Wrap(underlying, w http.ResponseWriter) http.ResponseWriter {
+	b := base{ResponseWriter: w, underlying: underlying}
+
+	// Prefer the implementations in w, falling back to underlying
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		flusher, _ = underlying.(http.Flusher)
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		hijacker, _ = underlying.(http.Hijacker)
+	}
+	pusher, ok := w.(http.Pusher)
+	if !ok {
+		pusher, _ = underlying.(http.Pusher)
+	}
+
+	// Expose only those interfaces the underlying writer supports
+	_, isFlusher := underlying.(http.Flusher)
+	_, isHijacker := underlying.(http.Hijacker)
+	_, isPusher := underlying.(http.Pusher)
+
+	switch {
+	case isFlusher && isHijacker && isPusher:
+		return flushHijackPush{b, flusher, hijacker, pusher}
+	case isFlusher && isHijacker:
+		return flushHijack{b, flusher, hijacker}
+	case isFlusher && isPusher:
+		return flushPush{b, flusher, pusher}
+	case isHijacker && isPusher:
+		return hijackPush{b, hijacker, pusher}
+	case isFlusher:
+		return flush{b, flusher}
+	case isHijacker:
+		return hijack{b, hijacker}
+	case isPusher:
+		return push{b, pusher}
+	}
+	return b
+}
+
+// Unwrap returns the innermost writer, unwrapping writers returned by Wrap
+// and any other writers with an Unwrap method.
+func Unwrap(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+		w = u.Unwrap()
+	}
+}
+
+// base wraps w and records the underlying writer for Unwrap
+type base struct {
+	http.ResponseWriter
+	underlying http.ResponseWriter
+}
+
+// Unwrap returns the underlying writer, for use by http.ResponseController
+func (b base) Unwrap() http.ResponseWriter {
+	return b.underlying
+}
+
+type flush struct {
+	base
+	http.Flusher
+}
+
+type hijack struct {
+	base
+	http.Hijacker
+}
+
+type push struct {
+	base
+	http.Pusher
+}
+
+type flushHijack struct {
+	base
+	http.Flusher
+	http.Hijacker
+}
+
+type flushPush struct {
+	base
+	http.Flusher
+	http.Pusher
+}
+
+type hijackPush struct {
+	base
+	http.Hijacker
+	http.Pusher
+}
+
+type flushHijackPush struct {
+	base
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+}
@@ -0,0 +1,153 @@
+package wrap
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// writer is a ResponseWriter with none of the optional interfaces
+type writer struct {
+	header   http.Header
+	flushed  int
+	deadline time.Time
+}
+
+func (w *writer) Header() http.Header {
+	if w.header == nil {
+		w.header = http.Header{}
+	}
+	return w.header
+}
+
+func (w *writer) Write(b []byte) (int, error) { return len(b), nil }
+func (w *writer) WriteHeader(code int)        {}
+
+// flusher, hijacker and pusher add the optional interfaces to a writer
+type flusher struct{ *writer }
+
+func (f flusher) Flush() { f.flushed++ }
+
+type hijacker struct{}
+
+func (hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+type pusher struct{}
+
+func (pusher) Push(string, *http.PushOptions) error { return nil }
+
+// Writers with each combination of the optional interfaces
+type (
+	flushWriter struct {
+		*writer
+		flusher
+	}
+	hijackWriter struct {
+		*writer
+		hijacker
+	}
+	pushWriter struct {
+		*writer
+		pusher
+	}
+	flushHijackWriter struct {
+		*writer
+		flusher
+		hijacker
+	}
+	flushPushWriter struct {
+		*writer
+		flusher
+		pusher
+	}
+	hijackPushWriter struct {
+		*writer
+		hijacker
+		pusher
+	}
+	flushHijackPushWriter struct {
+		*writer
+		flusher
+		hijacker
+		pusher
+	}
+)
+
+// deadlineWriter is a writer supporting write deadlines for http.ResponseController
+type deadlineWriter struct{ *writer }
+
+func (w deadlineWriter) SetWriteDeadline(t time.Time) error {
+	w.deadline = t
+	return nil
+}
+
+// TestWrap tests the optional interfaces of the underlying writer are exposed, and no others.
+func TestWrap(t *testing.T) {
+	w := &writer{}
+	tests := []struct {
+		underlying                http.ResponseWriter
+		flusher, hijacker, pusher bool
+	}{
+		{w, false, false, false},
+		{flushWriter{w, flusher{w}}, true, false, false},
+		{hijackWriter{w, hijacker{}}, false, true, false},
+		{pushWriter{w, pusher{}}, false, false, true},
+		{flushHijackWriter{w, flusher{w}, hijacker{}}, true, true, false},
+		{flushPushWriter{w, flusher{w}, pusher{}}, true, false, true},
+		{hijackPushWriter{w, hijacker{}, pusher{}}, false, true, true},
+		{flushHijackPushWriter{w, flusher{w}, hijacker{}, pusher{}}, true, true, true},
+	}
+
+	for _, tc := range tests {
+		wrapped := Wrap(tc.underlying, &writer{})
+		_, isFlusher := wrapped.(http.Flusher)
+		_, isHijacker := wrapped.(http.Hijacker)
+		_, isPusher := wrapped.(http.Pusher)
+		if isFlusher != tc.flusher || isHijacker != tc.hijacker || isPusher != tc.pusher {
+			t.Errorf("wrap: wrong interfaces for %T got:%t,%t,%t want:%t,%t,%t", tc.underlying, isFlusher, isHijacker, isPusher, tc.flusher, tc.hijacker, tc.pusher)
+		}
+		if Unwrap(wrapped) != tc.underlying {
+			t.Errorf("wrap: wrong unwrapped writer for %T", tc.underlying)
+		}
+	}
+}
+
+// TestWrapFlush tests the Flush method of the wrapping writer is preferred.
+func TestWrapFlush(t *testing.T) {
+	u := &writer{}
+	w := &writer{}
+	wrapped := Wrap(flushWriter{u, flusher{u}}, flushWriter{w, flusher{w}})
+	wrapped.(http.Flusher).Flush()
+	if w.flushed != 1 || u.flushed != 0 {
+		t.Errorf("wrap: wrong flush got:%d,%d want:1,0", w.flushed, u.flushed)
+	}
+
+	// Writers without Flush use the underlying writer
+	wrapped = Wrap(flushWriter{u, flusher{u}}, w)
+	wrapped.(http.Flusher).Flush()
+	if u.flushed != 1 {
+		t.Errorf("wrap: underlying writer not flushed")
+	}
+}
+
+// TestWrapResponseController tests http.ResponseController reaches the underlying writer.
+func TestWrapResponseController(t *testing.T) {
+	u := &writer{}
+	wrapped := Wrap(Wrap(deadlineWriter{u}, &writer{}), &writer{})
+
+	deadline := time.Now().Add(time.Minute)
+	if err := http.NewResponseController(wrapped).SetWriteDeadline(deadline); err != nil {
+		t.Fatalf("wrap: error setting deadline %s", err)
+	}
+	if !u.deadline.Equal(deadline) {
+		t.Errorf("wrap: deadline not set on underlying writer got:%s", u.deadline)
+	}
+
+	// Writers without deadlines report that they are not supported
+	err := http.NewResponseController(Wrap(&writer{}, &writer{})).SetWriteDeadline(deadline)
+	if err == nil {
+		t.Errorf("wrap: no error setting unsupported deadline")
+	}
+}
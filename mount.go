@@ -0,0 +1,80 @@
+package mux
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// mountMethods are the methods accepted by mounted handlers
+var mountMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// Mount adds a route passing requests with any method for all paths below prefix
+// to h, which is given the unmodified request, for example to mount a protocol
+// translator like a JSON-RPC server or gRPC gateway with m.Mount("/rpc/", gateway).
+// Mounted routes are matched by prefix before other routes, and are marked
+// RawBody, so the body and trailers are left for h.
+func (m *Mux) Mount(prefix string, h http.Handler) Route {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	route := m.Add(prefix+"{path:.*}", func(w http.ResponseWriter, r *http.Request) error {
+		h.ServeHTTP(w, r)
+		return nil
+	}).Methods(mountMethods...).RawBody()
+	m.statics = append(m.statics, staticRoute{prefix: prefix, route: route})
+	m.handlerNames[route] = fmt.Sprintf("%T", h)
+	return route
+}
+
+// ContentTypes dispatches requests to handlers by their media type,
+// for example to serve gRPC and JSON requests at the same mount point:
+//
+//	m.Mount("/api/", mux.ContentTypes{"application/grpc": grpcServer, "": gateway})
+//
+// Media types match by prefix, so application/grpc also matches
+// application/grpc+proto, and the longest match is used. The handler for ""
+// serves requests without a match, if it is not set they receive
+// 415 Unsupported Media Type.
+type ContentTypes map[string]http.Handler
+
+// ServeHTTP implements net/http.Handler.
+func (c ContentTypes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h := c.handler(r.Header.Get("Content-Type"))
+	if h == nil {
+		http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+		return
+	}
+	h.ServeHTTP(w, r)
+}
+
+// handler returns the handler for the media type of contentType, or nil if none
+func (c ContentTypes) handler(contentType string) http.Handler {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+
+	match := ""
+	for t := range c {
+		if t != "" && len(t) > len(match) && strings.HasPrefix(mediaType, strings.ToLower(t)) {
+			match = t
+		}
+	}
+	return c[match]
+}
+
+// StripPrefix returns a shallow copy of r with prefix (such as /fr) removed from
+// the start of the path, for middleware which reads a tenant or locale from the path.
+func StripPrefix(r *http.Request, prefix string) *http.Request {
+	r2 := r.WithContext(r.Context())
+	u := *r.URL
+	u.Path = stripPrefix(u.Path, prefix)
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
@@ -0,0 +1,86 @@
+package mux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMount tests mounted handlers receive requests below the prefix with the body unread.
+func TestMount(t *testing.T) {
+	m := New()
+	m.Mount("/rpc", ContentTypes{
+		"application/grpc": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("grpc"))
+		}),
+		"application/json": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			params, err := Params(r)
+			if err != nil {
+				t.Errorf("mount: error parsing params:%s", err)
+			}
+			body, _ := io.ReadAll(r.Body)
+			w.Write([]byte(r.URL.Path + " " + params.Get("path") + " " + string(body)))
+		}),
+	})
+
+	tests := []struct {
+		contentType string
+		code        int
+		body        string
+	}{
+		{"application/grpc+proto", http.StatusOK, "grpc"},
+		{"application/json; charset=utf-8", http.StatusOK, `/rpc/users.get users.get {"id":1}`},
+		{"text/plain", http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/rpc/users.get", strings.NewReader(`{"id":1}`))
+		r.Header.Set("Content-Type", tc.contentType)
+		m.ServeHTTP(w, r)
+		if w.Code != tc.code || (tc.body != "" && w.Body.String() != tc.body) {
+			t.Errorf("mount: wrong response for %s got:%d %s", tc.contentType, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestRawBody tests Params leaves the body unread for RawBody routes.
+func TestRawBody(t *testing.T) {
+	m := New()
+	m.Post("/upload/{id:\\d+}", func(w http.ResponseWriter, r *http.Request) error {
+		params, err := Params(r)
+		if err != nil {
+			return err
+		}
+		if params.Exists("name") {
+			t.Errorf("rawbody: body params parsed")
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(params.Get("id") + " " + string(body)))
+		return nil
+	}).RawBody()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/upload/3", strings.NewReader("name=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	m.ServeHTTP(w, r)
+	if w.Body.String() != "3 name=x" {
+		t.Errorf("rawbody: wrong body got:%s", w.Body.String())
+	}
+}
+
+// TestStripPrefix tests path prefixes are removed from a copy of the request.
+func TestStripPrefix(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/fr/pages?q=1", nil)
+	tests := map[string]string{"/fr": "/pages", "/fr/pages": "/", "/de": "/fr/pages"}
+	for prefix, want := range tests {
+		r2 := StripPrefix(r, prefix)
+		if r2.URL.Path != want || r2.URL.RawQuery != "q=1" {
+			t.Errorf("mount: wrong path stripping %s got:%s want:%s", prefix, r2.URL.Path, want)
+		}
+	}
+	if r.URL.Path != "/fr/pages" {
+		t.Errorf("mount: original request modified got:%s", r.URL.Path)
+	}
+}
@@ -3,7 +3,10 @@ package mux
 import (
 	"net/http"
 	"strings"
-	"sync"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+	"github.com/fragmenta/mux/middleware/wrap"
 )
 
 // HandlerFunc defines a std net/http HandlerFunc, but which returns an error.
@@ -16,6 +19,8 @@ type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
 type Middleware func(http.HandlerFunc) http.HandlerFunc
 
 // Route defines the interface routes are expected to conform to.
+// Methods may be added to this interface, so implementations outside
+// this package should embed *NaiveRoute (see Upgrading in the README).
 type Route interface {
 	// Match against URL
 	MatchMethod(string) bool
@@ -27,6 +32,13 @@ type Route interface {
 
 	// Parse the URL for params according to pattern
 	Parse(string) map[string]string
+	ParseInto(string, []Param) []Param
+
+	// Pattern returns the pattern the route was created with
+	Pattern() string
+
+	// AllowedMethods returns the methods accepted by the route
+	AllowedMethods() []string
 
 	// Set accepted methods
 	Get() Route
@@ -34,19 +46,46 @@ type Route interface {
 	Put() Route
 	Delete() Route
 	Methods(...string) Route
+
+	// Declare critical assets to preload
+	Preload(...string) Route
+	Preloads() []string
+
+	// Roll out new handlers gradually
+	Canary(HandlerFunc, int) Route
+	Shadow(HandlerFunc) Route
+
+	// Leave the request body for the handler to read
+	RawBody() Route
+	IsRawBody() bool
+
+	// Describe the route for middleware and generators
+	Meta(string, interface{}) Route
+	Tag(...string) Route
+	Metadata() map[string]interface{}
+	Tags() []string
+
+	// Retire the route
+	Deprecated(time.Time, string) Route
+	Deprecation() *Deprecation
 }
 
-// MaxCacheEntries defines the maximum number of entries in the request->route cache
+// MaxCacheEntries defines the maximum number of entries in the request->route cache,
+// entries not used recently are evicted when the cache is full.
 // 0 means caching is turned off
 var MaxCacheEntries = 500
 
-// mux is a private variable which is set only once on startup.
+// mux is a private variable which is set only once on startup,
+// it is used to find routes for requests not served by a mux.
 var mux *Mux
 
-// SetDefault sets the default mux on the package for use in parsing params
-// we could instead decorate each request with a reference to the Route
-// but this means extra allocations for each request,
-// when almost all apps require only one mux.
+// SetDefault sets the default mux on the package, which is used to parse
+// params for requests which were not routed by a mux (for example in tests).
+// It also registers the mux with http.Handle("/").
+//
+// Deprecated: Params now finds the route from the request context, so a
+// default mux is not required. Use http.ListenAndServe(addr, m) or
+// http.Handle("/", m) explicitly instead.
 func SetDefault(m *Mux) {
 	if mux == nil {
 		mux = m
@@ -56,22 +95,63 @@ func SetDefault(m *Mux) {
 	}
 }
 
+// RouteFromContext returns the route stored on the request by the mux,
+// or nil if the request has not been routed.
+func RouteFromContext(r *http.Request) Route {
+	route, _ := Value[Route](r, KeyRoute)
+	return route
+}
+
+// withRoute returns r with route stored under KeyRoute,
+// adding a values store to the request if required.
+func withRoute(r *http.Request, route Route) *http.Request {
+	r = WithValues(r)
+	SetValue(r, KeyRoute, route)
+	return r
+}
+
 // Mux handles http requests by selecting a handler
 // and passing the request to it.
 // Routes are evaluated in the order they were added.
 // Before the request reaches the handler
 // it is passed through the middleware chain.
 type Mux struct {
-	cache   map[string]Route
-	cacheMu sync.RWMutex
+	cache *routeCache
 
 	routes       []Route
+	statics      []staticRoute
 	handlerFuncs []Middleware
 
+	// handlerNames records names of handlers wrapped by AddHandler
+	handlerNames map[Route]string
+
 	// See httptrace for best way to instrument
 	ErrorHandler ErrorHandlerFunc
 	FileHandler  HandlerFunc
 	RedirectWWW  bool
+
+	// DebugRoutes enables the route added by AddRoutesDebug
+	DebugRoutes bool
+
+	// Concurrency limits, see LimitConcurrency and LimitRoute
+	limiter       *limiter
+	limitWait     time.Duration
+	routeLimiters map[Route]*limiter
+
+	// Hooks called outside the middleware chain, see OnRequest and OnResponse
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+	timingHooks   []TimingHook
+
+	// ParamTypeErrors matches routes with typed params such as {id:int} whatever
+	// the param values, and passes a 400 StatusError to the ErrorHandler for values
+	// of the wrong type before the handler runs. By default such requests do not match.
+	// Routes which match exactly are preferred, wherever they are in the route list.
+	ParamTypeErrors bool
+
+	// EarlyHints sends a 103 Early Hints response with the Link headers
+	// for routes with preloads before calling the handler.
+	EarlyHints bool
 }
 
 // New returns a new mux
@@ -80,7 +160,8 @@ func New() *Mux {
 		RedirectWWW:  false,
 		FileHandler:  fileHandler,
 		ErrorHandler: errHandler,
-		cache:        make(map[string]Route, MaxCacheEntries),
+		cache:        newRouteCache(),
+		handlerNames: make(map[Route]string),
 	}
 
 	return m
@@ -94,6 +175,34 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, redirect, http.StatusMovedPermanently)
 	}
 
+	// Add a store for request values shared by middleware and handlers
+	r = WithValues(r)
+
+	// Call hooks outside the middleware chain if any are set
+	if len(m.requestHooks) > 0 || len(m.responseHooks) > 0 {
+		m.serveWithHooks(w, r)
+		return
+	}
+
+	m.serve(w, r)
+}
+
+// serve passes the request through the middleware chain to RouteRequest
+func (m *Mux) serve(w http.ResponseWriter, r *http.Request) {
+	// Record the time spent in each stage if timing hooks are set
+	if len(m.timingHooks) > 0 {
+		defer m.startTiming(r)()
+	}
+
+	// Reject requests if too many are in flight
+	if m.limiter != nil {
+		if !m.limiter.acquire(r, m.limitWait) {
+			m.rejectLimited(w, r)
+			return
+		}
+		defer m.limiter.release()
+	}
+
 	// Avoid iteration if possible
 	if len(m.handlerFuncs) == 0 {
 		m.RouteRequest(w, r)
@@ -108,20 +217,85 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // RouteRequest is the final endpoint of all requests
 func (m *Mux) RouteRequest(w http.ResponseWriter, r *http.Request) {
+	t := m.timing(r)
+	var start time.Time
+	if t != nil {
+		start = time.Now()
+		defer func() { t.route = time.Since(start) }()
+	}
+
 	// Match a route
 	route := m.Match(r)
+	if t != nil {
+		t.Match = time.Since(start)
+	}
 	if route == nil {
 		err := m.FileHandler(w, r)
 		if err != nil {
-			m.ErrorHandler(w, r, err)
+			m.handleError(w, r, err)
 		}
 		return
 	}
 
+	// Store the route on the request so that Params need not match again
+	r = withRoute(r, route)
+
+	// Record the matched route for request loggers
+	log.SetField(r, log.FieldRoute, route.Pattern())
+
+	// Reject params of the wrong type for routes with typed params
+	if m.ParamTypeErrors {
+		if tr, ok := route.(typedRoute); ok {
+			if err := tr.checkParamTypes(r.URL.Path); err != nil {
+				m.handleError(w, r, err)
+				return
+			}
+		}
+	}
+
+	// Reject requests if too many are in flight for this route
+	if l := m.routeLimiters[route]; l != nil {
+		if !l.acquire(r, m.limitWait) {
+			m.rejectLimited(w, r)
+			return
+		}
+		defer l.release()
+	}
+
+	// Warn clients of deprecated routes and record their use
+	if d := route.Deprecation(); d != nil {
+		d.write(w, r, route)
+	}
+
+	// Skip the handler if the client has already gone away
+	if err := Cancelled(r); err != nil {
+		m.handleError(w, r, err)
+		return
+	}
+
+	// Serve HEAD requests with the GET handler, discarding the body
+	if r.Method == http.MethodHead {
+		hw := &headWriter{ResponseWriter: w}
+		w = wrap.Wrap(w, hw)
+		defer hw.finish()
+	}
+
+	// Send preload headers for critical assets if the route has any
+	if preloads := route.Preloads(); len(preloads) > 0 {
+		m.writePreloads(w, r, preloads)
+	}
+
 	// Execute the route
+	var handlerStart time.Time
+	if t != nil {
+		handlerStart = time.Now()
+	}
 	err := route.Handler()(w, r)
+	if t != nil {
+		t.Handler = time.Since(handlerStart)
+	}
 	if err != nil {
-		m.ErrorHandler(w, r, err)
+		m.handleError(w, r, err)
 	}
 
 }
@@ -133,19 +307,25 @@ func (m *Mux) Match(r *http.Request) Route {
 		return nil
 	}
 
-	// Check if we have a cached result for this same path
+	// Static routes are matched by prefix alone
+	if len(m.statics) > 0 {
+		if route := m.matchStatic(r); route != nil {
+			return route
+		}
+	}
+
+	// Check if we have a cached result for this same method and path
+	key := cacheKey{method: r.Method, path: r.URL.Path}
 	if MaxCacheEntries > 0 {
-		m.cacheMu.RLock()
-		route, ok := m.cache[requestCacheKey(r)]
-		m.cacheMu.RUnlock()
-		// This check is necessary as we only use the request url for the cache key
-		// this means we get a cache miss on some identical routes with diff methods.
+		route, ok := m.cache.get(key)
+		// Methods may be changed on routes after they are added, so check again
 		if ok && route.MatchMethod(r.Method) {
 			return route
 		}
 	}
 
 	// Routes are checked in order against the request path
+	var loose Route
 	for _, route := range m.routes {
 		// Test with probabalistic match
 		if route.MatchMaybe(r.URL.Path) {
@@ -153,37 +333,38 @@ func (m *Mux) Match(r *http.Request) Route {
 			if route.MatchMethod(r.Method) {
 				// Test exact match (may be expensive regexp)
 				if route.Match(r.URL.Path) {
-					m.cacheRoute(requestCacheKey(r), route)
+					m.cacheRoute(key, route)
 					return route
 				}
+
+				// Record the first route which matches except for param types
+				if m.ParamTypeErrors && loose == nil && matchLoose(route, r.URL.Path) {
+					loose = route
+				}
 			}
 
 		}
 	}
 
-	return nil
-}
-
-// Return a key suitable for storing this request in our cache.
-// NB: To avoid allocations we do not include every permutation in the cache
-// so routes returned must be checked against request.
-func requestCacheKey(r *http.Request) string {
-	return r.URL.Path
+	if loose != nil {
+		m.cacheRoute(key, loose)
+	}
+	return loose
 }
 
 // cacheRoute saves the route with key provided
-func (m *Mux) cacheRoute(key string, r Route) {
+func (m *Mux) cacheRoute(key cacheKey, r Route) {
 	if MaxCacheEntries == 0 {
 		return // MaxCacheEntries is 0 so cache is off
 	}
-	m.cacheMu.Lock()
-	// If cache is too big, evict
-	if len(m.cache) > MaxCacheEntries {
-		m.cache = make(map[string]Route, MaxCacheEntries)
-	}
-	// Fill the cache for this key -> route pair
-	m.cache[key] = r
-	m.cacheMu.Unlock()
+	m.cache.add(key, r, MaxCacheEntries)
+}
+
+// Routes returns the routes registered on the mux in the order they are evaluated.
+func (m *Mux) Routes() []Route {
+	routes := make([]Route, len(m.routes))
+	copy(routes, m.routes)
+	return routes
 }
 
 // AddMiddleware adds a middleware function, this should be done before
@@ -196,10 +377,13 @@ func (m *Mux) AddMiddleware(middleware Middleware) {
 // AddHandler adds a route for this pattern using a
 // stdlib http.HandlerFunc which does not return an error.
 func (m *Mux) AddHandler(pattern string, handler http.HandlerFunc) Route {
-	return m.Add(pattern, func(w http.ResponseWriter, r *http.Request) error {
+	route := m.Add(pattern, func(w http.ResponseWriter, r *http.Request) error {
 		handler(w, r)
 		return nil
 	})
+	// Record the name of the wrapped handler for route listings
+	m.handlerNames[route] = funcName(handler)
+	return route
 }
 
 // Add adds a route for this request with the default methods (GET/HEAD)
@@ -212,6 +396,9 @@ func (m *Mux) Add(pattern string, handler HandlerFunc) Route {
 	}
 
 	m.routes = append(m.routes, route)
+
+	// Invalidate cached matches as the route table has changed
+	m.cache.reset()
 	return route
 }
 
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/fragmenta/mux/log"
 )
 
 var routes = []string{
@@ -538,3 +540,36 @@ func TestCache(t *testing.T) {
 	}
 
 }
+
+// TestRequestFields tests the matched route is recorded in request log fields.
+func TestRequestFields(t *testing.T) {
+	m := New()
+	m.Get(`/users/{id:\d+}`, func(w http.ResponseWriter, r *http.Request) error {
+		if log.GetField(r, log.FieldRoute) != `/users/{id:\d+}` {
+			t.Errorf("mux: route field not set got:%v", log.GetField(r, log.FieldRoute))
+		}
+		return nil
+	})
+
+	r := log.WithFields(httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	m.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+// TestRoutes tests introspection of the routes on a mux.
+func TestRoutes(t *testing.T) {
+	m := New()
+	m.Get("/", handler)
+	m.Post(`/users/{id:\d+}/update`, handler)
+
+	routes := m.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("mux: wrong number of routes got:%d", len(routes))
+	}
+	if routes[1].Pattern() != `/users/{id:\d+}/update` {
+		t.Errorf("mux: wrong route pattern got:%s", routes[1].Pattern())
+	}
+	methods := routes[0].AllowedMethods()
+	if len(methods) != 2 || methods[0] != http.MethodGet || methods[1] != http.MethodHead {
+		t.Errorf("mux: wrong route methods got:%v", methods)
+	}
+}
@@ -0,0 +1,253 @@
+// Package muxtest provides helpers for testing handlers served by a mux,
+// building requests with query, form, json and multipart bodies,
+// executing them and asserting on the matched route and response.
+package muxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fragmenta/mux"
+)
+
+// Usage
+// m := mux.New()
+// m.Post(`/users/{id:\d+}/update`, users.HandleUpdate)
+// ...
+// res := muxtest.NewRequest(http.MethodPost, "/users/1/update").Form(url.Values{"name": {"alice"}}).Do(t, m)
+// res.AssertRoute(`/users/{id:\d+}/update`).AssertStatus(http.StatusFound)
+
+// RequestBuilder builds requests for testing, methods may be chained.
+type RequestBuilder struct {
+	method  string
+	path    string
+	query   url.Values
+	header  http.Header
+	body    io.Reader
+	err     error
+	cookies []*http.Cookie
+}
+
+// NewRequest returns a builder for a request with this method and path,
+// the path may include a query string.
+func NewRequest(method, path string) *RequestBuilder {
+	return &RequestBuilder{
+		method: method,
+		path:   path,
+		query:  url.Values{},
+		header: http.Header{},
+	}
+}
+
+// Get returns a builder for a GET request to path
+func Get(path string) *RequestBuilder {
+	return NewRequest(http.MethodGet, path)
+}
+
+// Post returns a builder for a POST request to path
+func Post(path string) *RequestBuilder {
+	return NewRequest(http.MethodPost, path)
+}
+
+// Query adds a query parameter to the request url.
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// Header sets a header on the request.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+// Cookie adds a cookie to the request.
+func (b *RequestBuilder) Cookie(c *http.Cookie) *RequestBuilder {
+	b.cookies = append(b.cookies, c)
+	return b
+}
+
+// Body sets the request body and content type.
+func (b *RequestBuilder) Body(contentType string, body io.Reader) *RequestBuilder {
+	b.header.Set("Content-Type", contentType)
+	b.body = body
+	return b
+}
+
+// Form sets a url encoded form body.
+func (b *RequestBuilder) Form(values url.Values) *RequestBuilder {
+	return b.Body("application/x-www-form-urlencoded", strings.NewReader(values.Encode()))
+}
+
+// JSON sets a json body encoded from v.
+func (b *RequestBuilder) JSON(v interface{}) *RequestBuilder {
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.err = err
+	}
+	return b.Body("application/json", bytes.NewReader(data))
+}
+
+// Multipart sets a multipart form body with the values and files given,
+// files are a map of field name to file name to file contents.
+func (b *RequestBuilder) Multipart(values url.Values, files map[string]map[string][]byte) *RequestBuilder {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	for k, vs := range values {
+		for _, v := range vs {
+			err := w.WriteField(k, v)
+			if err != nil {
+				b.err = err
+			}
+		}
+	}
+
+	for field, fileMap := range files {
+		for name, contents := range fileMap {
+			fw, err := w.CreateFormFile(field, name)
+			if err != nil {
+				b.err = err
+				continue
+			}
+			fw.Write(contents)
+		}
+	}
+
+	err := w.Close()
+	if err != nil {
+		b.err = err
+	}
+
+	return b.Body(w.FormDataContentType(), &body)
+}
+
+// Build returns the request, it panics if the body could not be encoded.
+func (b *RequestBuilder) Build() *http.Request {
+	if b.err != nil {
+		panic("muxtest: error building request: " + b.err.Error())
+	}
+
+	r := httptest.NewRequest(b.method, b.path, b.body)
+	if len(b.query) > 0 {
+		q := r.URL.Query()
+		for k, vs := range b.query {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+		r.URL.RawQuery = q.Encode()
+	}
+	for k, vs := range b.header {
+		r.Header[k] = vs
+	}
+	for _, c := range b.cookies {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+// Do builds the request and executes it against m.
+func (b *RequestBuilder) Do(t testing.TB, m *mux.Mux) *Response {
+	t.Helper()
+	return Do(t, m, b.Build())
+}
+
+// Do executes the request against m, recording the matched route and response.
+// The route is the one stored on the request while serving it, so it reflects
+// any rewriting by middleware or mounted muxes.
+func Do(t testing.TB, m *mux.Mux, r *http.Request) *Response {
+	t.Helper()
+	res := &Response{
+		ResponseRecorder: httptest.NewRecorder(),
+		t:                t,
+	}
+
+	// Add the values store here so that the route set by the mux is visible after serving
+	r = mux.WithValues(r)
+	m.ServeHTTP(res.ResponseRecorder, r)
+	res.Route = mux.RouteFromContext(r)
+	return res
+}
+
+// Response records the result of executing a request, assertions report
+// failures to the test and return the response so that they may be chained.
+type Response struct {
+	*httptest.ResponseRecorder
+
+	// Route is the route matched by the request, or nil if none matched
+	Route mux.Route
+
+	t testing.TB
+}
+
+// AssertStatus asserts the response status code is status.
+func (r *Response) AssertStatus(status int) *Response {
+	r.t.Helper()
+	if r.Code != status {
+		r.t.Errorf("muxtest: wrong status want:%d got:%d", status, r.Code)
+	}
+	return r
+}
+
+// AssertRoute asserts the request matched a route with this pattern,
+// pass "" to assert no route was matched.
+func (r *Response) AssertRoute(pattern string) *Response {
+	r.t.Helper()
+	got := ""
+	if r.Route != nil {
+		got = r.Route.Pattern()
+	}
+	if got != pattern {
+		r.t.Errorf("muxtest: wrong route want:%q got:%q", pattern, got)
+	}
+	return r
+}
+
+// AssertHeader asserts the response header key had value when the response was written.
+func (r *Response) AssertHeader(key, value string) *Response {
+	r.t.Helper()
+	if got := r.Result().Header.Get(key); got != value {
+		r.t.Errorf("muxtest: wrong header %s want:%q got:%q", key, value, got)
+	}
+	return r
+}
+
+// AssertBodyContains asserts the response body contains s.
+func (r *Response) AssertBodyContains(s string) *Response {
+	r.t.Helper()
+	if !strings.Contains(r.Body.String(), s) {
+		r.t.Errorf("muxtest: body does not contain %q got:%q", s, r.Body.String())
+	}
+	return r
+}
+
+// DecodeJSON decodes the json response body into v, failing the test on error.
+func (r *Response) DecodeJSON(v interface{}) *Response {
+	r.t.Helper()
+	err := json.Unmarshal(r.Body.Bytes(), v)
+	if err != nil {
+		r.t.Errorf("muxtest: error decoding json body:%s", err)
+	}
+	return r
+}
+
+// Params returns RequestParams with the values given, for testing
+// code which accepts params without building a request.
+func Params(values url.Values) *mux.RequestParams {
+	params := &mux.RequestParams{
+		Values: url.Values{},
+		Files:  make(map[string][]*multipart.FileHeader),
+	}
+	for k, v := range values {
+		params.Add(k, v)
+	}
+	return params
+}
@@ -0,0 +1,59 @@
+package muxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/fragmenta/mux"
+)
+
+// TestDo tests requests are built and served, recording the route and response.
+func TestDo(t *testing.T) {
+	m := mux.New()
+	m.Post(`/users/{id:\d+}/update`, func(w http.ResponseWriter, r *http.Request) error {
+		params, err := mux.Params(r)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("X-Name", params.Get("name"))
+		w.WriteHeader(http.StatusFound)
+		// Headers changed after writing are not sent
+		w.Header().Set("X-Name", "changed")
+		return nil
+	})
+
+	res := Post("/users/1/update").Form(url.Values{"name": {"alice"}}).Do(t, m)
+	res.AssertRoute(`/users/{id:\d+}/update`).AssertStatus(http.StatusFound).AssertHeader("X-Name", "alice")
+
+	Get("/missing").Do(t, m).AssertRoute("").AssertStatus(http.StatusNotFound)
+}
+
+// TestDoMount tests the route recorded is the one which served the request.
+func TestDoMount(t *testing.T) {
+	api := mux.New()
+	api.Get(`/api/users/{id:\d+}`, func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	m := mux.New()
+	m.Mount("/api/", api)
+
+	Get("/api/users/1").Do(t, m).AssertRoute(`/api/users/{id:\d+}`).AssertStatus(http.StatusOK)
+}
+
+// TestBuild tests query params, headers and json bodies are set on requests.
+func TestBuild(t *testing.T) {
+	r := Get("/search?q=a").Query("page", "2").Header("Accept", "application/json").Build()
+	if r.URL.Query().Get("q") != "a" || r.URL.Query().Get("page") != "2" || r.Header.Get("Accept") != "application/json" {
+		t.Errorf("muxtest: wrong request got:%s %v", r.URL, r.Header)
+	}
+
+	r = Post("/users").JSON(map[string]string{"name": "alice"}).Build()
+	var body map[string]string
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body["name"] != "alice" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("muxtest: wrong json body got:%v %v", body, err)
+	}
+}
@@ -0,0 +1,182 @@
+// Package negotiate parses the Accept, Accept-Language and Accept-Encoding
+// request headers, and chooses the best of the representations a server offers.
+package negotiate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Usage
+// switch negotiate.ContentType(r.Header.Get("Accept"), "application/json", "text/html") {
+// case "application/json":
+// ...
+// locale := negotiate.Language(r.Header.Get("Accept-Language"), "en", "fr")
+
+// Spec is a value listed in an Accept header with its quality.
+type Spec struct {
+	Value string
+	Q     float64
+}
+
+// Parse returns the values listed in an Accept style header, lowercased and
+// sorted by quality, with values of equal quality in the order listed.
+// Values with q=0 are included, as they mark values which are not acceptable.
+func Parse(header string) []Spec {
+	var specs []Spec
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(params[0]))
+		if value == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if len(p) > 2 && (p[0] == 'q' || p[0] == 'Q') && p[1] == '=' {
+				f, err := strconv.ParseFloat(p[2:], 64)
+				if err == nil && f >= 0 && f <= 1 {
+					q = f
+				}
+			}
+		}
+		specs = append(specs, Spec{Value: value, Q: q})
+	}
+	sort.SliceStable(specs, func(i, j int) bool { return specs[i].Q > specs[j].Q })
+	return specs
+}
+
+// MatchFunc returns the specificity with which the header value spec matches offer,
+// higher values for more specific matches, or -1 if it does not match.
+type MatchFunc func(spec, offer string) int
+
+// BestMatch returns the offer preferred by the header given, using match to compare
+// values with offers, or "" if none of the offers is acceptable.
+// Each offer takes the quality of the most specific value matching it, and offers
+// are compared by quality, then specificity, then the order offered.
+func BestMatch(header string, match MatchFunc, offers ...string) string {
+	specs := Parse(header)
+
+	best := ""
+	bestQ, bestSpecificity := 0.0, -1
+	for _, offer := range offers {
+		q, specificity := quality(specs, match, strings.ToLower(offer))
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+	return best
+}
+
+// quality returns the quality of the most specific spec matching offer and its specificity
+func quality(specs []Spec, match MatchFunc, offer string) (float64, int) {
+	q, specificity := 0.0, -1
+	for _, spec := range specs {
+		s := match(spec.Value, offer)
+		if s > specificity {
+			q, specificity = spec.Q, s
+		}
+	}
+	return q, specificity
+}
+
+// ContentType returns the media type preferred by an Accept header, or "" if none
+// of the offers is acceptable. An empty header accepts the first offer.
+func ContentType(header string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+	return BestMatch(header, MatchMediaType, offers...)
+}
+
+// Language returns the language tag preferred by an Accept-Language header, or ""
+// if none of the offers is acceptable. An empty header accepts the first offer.
+func Language(header string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+	return BestMatch(header, MatchLanguage, offers...)
+}
+
+// Encoding returns the content coding preferred by an Accept-Encoding header, or ""
+// if none of the offers is acceptable. The identity coding is acceptable unless
+// excluded, and is the only coding acceptable if the header is empty.
+func Encoding(header string, offers ...string) string {
+	if strings.TrimSpace(header) == "" {
+		header = "identity"
+	} else if !listed(header, "identity", "*") {
+		// Identity has a low but acceptable quality unless the header sets one
+		header += ",identity;q=0.001"
+	}
+	return BestMatch(header, MatchEncoding, offers...)
+}
+
+// listed returns true if the header lists any of the values
+func listed(header string, values ...string) bool {
+	for _, spec := range Parse(header) {
+		for _, v := range values {
+			if spec.Value == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchMediaType matches media ranges like text/html, text/* or */* against media types.
+func MatchMediaType(spec, offer string) int {
+	switch {
+	case spec == offer:
+		return 2
+	case strings.HasSuffix(spec, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(spec, "*")):
+		return 1
+	case spec == "*/*" || spec == "*":
+		return 0
+	}
+	return -1
+}
+
+// MatchLanguage matches language ranges against language tags: exactly, by prefix
+// (en matches en-GB), by base language (en-GB matches en-US), or by the * range.
+func MatchLanguage(spec, offer string) int {
+	spec = strings.Replace(spec, "_", "-", -1)
+	offer = strings.Replace(offer, "_", "-", -1)
+	switch {
+	case spec == offer:
+		return 3
+	case strings.HasPrefix(offer, spec+"-"):
+		return 2
+	case baseLanguage(spec) == baseLanguage(offer):
+		return 1
+	case spec == "*":
+		return 0
+	}
+	return -1
+}
+
+// MatchEncoding matches content codings against codings, or the * coding.
+func MatchEncoding(spec, offer string) int {
+	switch {
+	case spec == offer, spec == "x-"+offer, "x-"+spec == offer:
+		return 1
+	case spec == "*":
+		return 0
+	}
+	return -1
+}
+
+// baseLanguage returns the language of a tag e.g. en for en-gb
+func baseLanguage(tag string) string {
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
@@ -0,0 +1,103 @@
+package negotiate
+
+import (
+	"testing"
+)
+
+// TestContentType tests media types are chosen by quality, then specificity.
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		header string
+		offers []string
+		want   string
+	}{
+		{"", []string{"text/html", "application/json"}, "text/html"},
+		{"application/json", []string{"text/html", "application/json"}, "application/json"},
+		{"text/html;q=0.5, application/json", []string{"text/html", "application/json"}, "application/json"},
+		{"*/*;q=0.1, application/json;q=0.5", []string{"text/html", "application/json"}, "application/json"},
+		// The most specific range sets the quality, so text/html is excluded here
+		{"text/*, text/html;q=0", []string{"text/html", "text/plain"}, "text/plain"},
+		{"*/*, application/json;q=0", []string{"application/json"}, ""},
+		{"text/*;q=0.5, */*;q=0.9", []string{"text/plain", "image/png"}, "image/png"},
+		// Equal quality prefers the more specific match, then the order offered
+		{"*/*, application/json", []string{"text/html", "application/json"}, "application/json"},
+		{"*/*", []string{"text/html", "application/json"}, "text/html"},
+		{"TEXT/HTML", []string{"text/html"}, "text/html"},
+		{"image/png", []string{"text/html", "application/json"}, ""},
+		{"text/html", nil, ""},
+	}
+
+	for _, tc := range tests {
+		got := ContentType(tc.header, tc.offers...)
+		if got != tc.want {
+			t.Errorf("negotiate: wrong content type for %q got:%q want:%q", tc.header, got, tc.want)
+		}
+	}
+}
+
+// TestLanguage tests language tags are matched exactly, by prefix and by base language.
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		offers []string
+		want   string
+	}{
+		{"", []string{"en", "fr"}, "en"},
+		{"fr-CH, fr;q=0.9, en;q=0.8", []string{"en", "fr"}, "fr"},
+		{"en", []string{"fr", "en-GB"}, "en-GB"},
+		{"en-US", []string{"fr", "en-GB"}, "en-GB"},
+		{"en-GB, en-US", []string{"en-US", "en-GB"}, "en-US"},
+		{"en_GB", []string{"en-GB"}, "en-GB"},
+		{"*, fr;q=0", []string{"fr", "de"}, "de"},
+		{"de", []string{"en", "fr"}, ""},
+	}
+
+	for _, tc := range tests {
+		got := Language(tc.header, tc.offers...)
+		if got != tc.want {
+			t.Errorf("negotiate: wrong language for %q got:%q want:%q", tc.header, got, tc.want)
+		}
+	}
+}
+
+// TestEncoding tests codings are chosen by quality, falling back to identity unless excluded.
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		offers []string
+		want   string
+	}{
+		{"", []string{"gzip", "identity"}, "identity"},
+		{"", []string{"gzip"}, ""},
+		{"gzip", []string{"br", "gzip", "identity"}, "gzip"},
+		{"br;q=1, gzip;q=0.5", []string{"gzip", "br"}, "br"},
+		{"x-gzip", []string{"gzip"}, "gzip"},
+		// Identity is acceptable unless excluded
+		{"br", []string{"gzip", "identity"}, "identity"},
+		{"br, identity;q=0", []string{"gzip", "identity"}, ""},
+		{"*;q=0", []string{"gzip", "identity"}, ""},
+		{"*", []string{"gzip", "identity"}, "gzip"},
+		{"gzip;q=0, *", []string{"gzip", "br"}, "br"},
+	}
+
+	for _, tc := range tests {
+		got := Encoding(tc.header, tc.offers...)
+		if got != tc.want {
+			t.Errorf("negotiate: wrong encoding for %q got:%q want:%q", tc.header, got, tc.want)
+		}
+	}
+}
+
+// TestParse tests header values are sorted by quality in a stable order.
+func TestParse(t *testing.T) {
+	specs := Parse("text/plain;q=0.5, TEXT/HTML, application/json;level=1;q=0.8, image/png;q=2, , text/csv;q=0")
+	want := []Spec{{"text/html", 1}, {"image/png", 1}, {"application/json", 0.8}, {"text/plain", 0.5}, {"text/csv", 0}}
+	if len(specs) != len(want) {
+		t.Fatalf("negotiate: wrong specs got:%v want:%v", specs, want)
+	}
+	for i := range want {
+		if specs[i] != want[i] {
+			t.Errorf("negotiate: wrong spec %d got:%v want:%v", i, specs[i], want[i])
+		}
+	}
+}
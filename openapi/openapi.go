@@ -0,0 +1,282 @@
+// Package openapi generates an OpenAPI 3 specification from the routes in a mux,
+// with optional metadata describing each route, and serves it as json or yaml.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fragmenta/mux"
+)
+
+// Usage
+// spec := openapi.New(openapi.Info{Title: "My API", Version: "1.0"})
+// spec.Describe(m.Get(`/users/{id:\d+}`, users.HandleShow), openapi.Operation{Summary: "Show a user", Response: User{}})
+// m.Get(`/openapi.json`, spec.Handler(m))
+
+// Version is the OpenAPI version of documents generated.
+const Version = "3.0.3"
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operation describes a route, all fields are optional.
+type Operation struct {
+	// Summary is a short summary of the operation
+	Summary string
+	// Description is a longer description of the operation
+	Description string
+	// OperationID is a unique id for the operation
+	OperationID string
+	// Tags group operations in documentation
+	Tags []string
+	// Request is a value whose type describes the json request body
+	Request interface{}
+	// Response is a value whose type describes the json response body
+	Response interface{}
+	// Query lists the names of query params accepted
+	Query []string
+	// Deprecated marks the operation as deprecated
+	Deprecated bool
+	// Hidden omits the route from the document
+	Hidden bool
+}
+
+// MetaKey is the route metadata key under which Describe stores the operation
+const MetaKey = "openapi"
+
+// Spec generates OpenAPI documents for the routes in a mux.
+type Spec struct {
+	Info    Info
+	Servers []string
+}
+
+// New returns a new spec with the info given.
+func New(info Info) *Spec {
+	return &Spec{
+		Info: info,
+	}
+}
+
+// Describe sets the operation for route in the route metadata,
+// and returns the route for chaining.
+// The metadata is used rather than the route itself, as routes returned
+// by chained methods like Post may not be those listed by the mux.
+func (s *Spec) Describe(route mux.Route, op Operation) mux.Route {
+	return route.Meta(MetaKey, op)
+}
+
+// Document returns the OpenAPI document for the routes in m.
+// Routes are documented in the order they were added.
+func (s *Spec) Document(m *mux.Mux) map[string]interface{} {
+	paths := map[string]interface{}{}
+	schemas := newSchemaGenerator()
+
+	for _, route := range m.Routes() {
+		op, _ := mux.RouteMeta[Operation](route, MetaKey)
+		op = routeOperation(route, op)
+		if op.Hidden {
+			continue
+		}
+
+		path, params := convertPattern(route.Pattern())
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+
+		for _, method := range route.AllowedMethods() {
+			// HEAD is implied by GET
+			if method == http.MethodHead {
+				continue
+			}
+			item[strings.ToLower(method)] = s.operation(op, params, method, schemas)
+		}
+	}
+
+	info := map[string]interface{}{
+		"title":   s.Info.Title,
+		"version": s.Info.Version,
+	}
+	if s.Info.Description != "" {
+		info["description"] = s.Info.Description
+	}
+
+	doc := map[string]interface{}{
+		"openapi": Version,
+		"info":    info,
+		"paths":   paths,
+	}
+
+	if len(s.Servers) > 0 {
+		var servers []interface{}
+		for _, u := range s.Servers {
+			servers = append(servers, map[string]interface{}{"url": u})
+		}
+		doc["servers"] = servers
+	}
+
+	if len(schemas.components) > 0 {
+		doc["components"] = map[string]interface{}{"schemas": schemas.components}
+	}
+
+	return doc
+}
+
+// JSON returns the OpenAPI document for m encoded as json.
+func (s *Spec) JSON(m *mux.Mux) ([]byte, error) {
+	return json.MarshalIndent(s.Document(m), "", "  ")
+}
+
+// YAML returns the OpenAPI document for m encoded as yaml.
+func (s *Spec) YAML(m *mux.Mux) ([]byte, error) {
+	return encodeYAML(s.Document(m))
+}
+
+// Handler returns a handler serving the document for m, as yaml if the
+// request path ends in .yaml or .yml and as json otherwise.
+func (s *Spec) Handler(m *mux.Mux) mux.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var data []byte
+		var err error
+		if strings.HasSuffix(r.URL.Path, ".yaml") || strings.HasSuffix(r.URL.Path, ".yml") {
+			w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+			data, err = s.YAML(m)
+		} else {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			data, err = s.JSON(m)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
+
+// Route tags read by Document
+const (
+	// TagHidden omits a route from the document
+	TagHidden = "docs:hidden"
+	// TagDeprecated marks a route as deprecated
+	TagDeprecated = "docs:deprecated"
+)
+
+// routeOperation returns op with fields not set by Describe taken from route metadata,
+// the tags TagHidden and TagDeprecated, the route deprecation, and the meta keys
+// summary and description.
+func routeOperation(route mux.Route, op Operation) Operation {
+	if mux.HasTag(route, TagHidden) {
+		op.Hidden = true
+	}
+	if mux.HasTag(route, TagDeprecated) || route.Deprecation() != nil {
+		op.Deprecated = true
+	}
+	if summary, ok := mux.RouteMeta[string](route, "summary"); ok && op.Summary == "" {
+		op.Summary = summary
+	}
+	if description, ok := mux.RouteMeta[string](route, "description"); ok && op.Description == "" {
+		op.Description = description
+	}
+	return op
+}
+
+// operation returns the OpenAPI operation object for a route method.
+func (s *Spec) operation(op Operation, params []interface{}, method string, schemas *schemaGenerator) map[string]interface{} {
+	o := map[string]interface{}{}
+	if op.Summary != "" {
+		o["summary"] = op.Summary
+	}
+	if op.Description != "" {
+		o["description"] = op.Description
+	}
+	if op.OperationID != "" {
+		o["operationId"] = op.OperationID
+	}
+	if len(op.Tags) > 0 {
+		o["tags"] = op.Tags
+	}
+	if op.Deprecated {
+		o["deprecated"] = true
+	}
+
+	parameters := append([]interface{}{}, params...)
+	for _, q := range op.Query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":   q,
+			"in":     "query",
+			"schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		o["parameters"] = parameters
+	}
+
+	if op.Request != nil && method != http.MethodGet && method != http.MethodDelete {
+		o["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemas.schema(op.Request)},
+			},
+		}
+	}
+
+	response := map[string]interface{}{"description": "OK"}
+	if op.Response != nil {
+		response["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemas.schema(op.Response)},
+		}
+	}
+	o["responses"] = map[string]interface{}{
+		strconv.Itoa(http.StatusOK): response,
+		"default":                   map[string]interface{}{"description": "Error"},
+	}
+
+	return o
+}
+
+// paramPattern matches params in mux patterns like {id:\d+}
+var paramPattern = regexp.MustCompile(`\{([^:{}]*):((?:[^{}]|\{[^{}]*\})*)\}`)
+
+// convertPattern converts a mux pattern /users/{id:\d+} to an OpenAPI path /users/{id}
+// and returns path parameters for each param in the pattern.
+func convertPattern(pattern string) (string, []interface{}) {
+	var params []interface{}
+	path := paramPattern.ReplaceAllStringFunc(pattern, func(p string) string {
+		match := paramPattern.FindStringSubmatch(p)
+		name, re := match[1], match[2]
+		if name == "" {
+			name = "param" + strconv.Itoa(len(params)+1)
+		}
+
+		// Anchor the pattern at both ends, as routes match whole segments
+		schema := map[string]interface{}{"type": "string", "pattern": "^(?:" + re + ")$"}
+		switch re {
+		case `\d+`, `[0-9]+`, "int", "uint":
+			schema = map[string]interface{}{"type": "integer"}
+		case "float":
+			schema = map[string]interface{}{"type": "number"}
+		case "bool":
+			schema = map[string]interface{}{"type": "boolean"}
+		case "uuid":
+			schema = map[string]interface{}{"type": "string", "format": "uuid"}
+		}
+
+		params = append(params, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+		return "{" + name + "}"
+	})
+	return path, params
+}
@@ -0,0 +1,241 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux"
+)
+
+// handler is used for routes in tests
+func handler(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// TestConvertPattern tests patterns are converted to paths with typed parameters.
+func TestConvertPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		schemas []map[string]interface{}
+		names   []string
+	}{
+		{"/users", "/users", nil, nil},
+		{`/users/{id:\d+}`, "/users/{id}", []map[string]interface{}{{"type": "integer"}}, []string{"id"}},
+		{"/users/{id:int}", "/users/{id}", []map[string]interface{}{{"type": "integer"}}, []string{"id"}},
+		{"/users/{id:uint}", "/users/{id}", []map[string]interface{}{{"type": "integer"}}, []string{"id"}},
+		{"/prices/{price:float}", "/prices/{price}", []map[string]interface{}{{"type": "number"}}, []string{"price"}},
+		{"/flags/{on:bool}", "/flags/{on}", []map[string]interface{}{{"type": "boolean"}}, []string{"on"}},
+		{"/keys/{key:uuid}", "/keys/{key}", []map[string]interface{}{{"type": "string", "format": "uuid"}}, []string{"key"}},
+		{"/pages/{slug:[a-z]+|home}", "/pages/{slug}", []map[string]interface{}{{"type": "string", "pattern": "^(?:[a-z]+|home)$"}}, []string{"slug"}},
+		{`/codes/{code:[A-Z]{3}}`, "/codes/{code}", []map[string]interface{}{{"type": "string", "pattern": "^(?:[A-Z]{3})$"}}, []string{"code"}},
+		{`/{:[a-z]+}/{id:int}/{:\d+}`, "/{param1}/{id}/{param3}", []map[string]interface{}{
+			{"type": "string", "pattern": "^(?:[a-z]+)$"}, {"type": "integer"}, {"type": "integer"},
+		}, []string{"param1", "id", "param3"}},
+	}
+
+	for _, tc := range tests {
+		path, params := convertPattern(tc.pattern)
+		if path != tc.path {
+			t.Errorf("openapi: wrong path for %s got:%s want:%s", tc.pattern, path, tc.path)
+		}
+		if len(params) != len(tc.schemas) {
+			t.Errorf("openapi: wrong params for %s got:%v", tc.pattern, params)
+			continue
+		}
+		for i, p := range params {
+			param := p.(map[string]interface{})
+			if param["name"] != tc.names[i] || param["in"] != "path" || param["required"] != true {
+				t.Errorf("openapi: wrong param for %s got:%v", tc.pattern, param)
+			}
+			if !reflect.DeepEqual(param["schema"], tc.schemas[i]) {
+				t.Errorf("openapi: wrong schema for %s got:%v want:%v", tc.pattern, param["schema"], tc.schemas[i])
+			}
+		}
+	}
+}
+
+// Address is a named struct used in schemas
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+// Node is a recursive struct used in schemas
+type Node struct {
+	Name     string  `json:"name"`
+	Children []*Node `json:"children"`
+	Parent   *Node   `json:"parent"`
+}
+
+// Timestamps is embedded in User
+type Timestamps struct {
+	Created time.Time `json:"created"`
+}
+
+// User is a struct with nested and embedded structs used in schemas
+type User struct {
+	Timestamps
+	ID      int64             `json:"id"`
+	Name    string            `json:"name"`
+	Admin   bool              `json:"admin,omitempty"`
+	Address Address           `json:"address"`
+	Tags    []string          `json:"tags"`
+	Extra   map[string]string `json:"extra"`
+	Avatar  []byte            `json:"avatar"`
+	Nick    *string           `json:"nick"`
+	Skipped string            `json:"-"`
+	secret  string
+	Options struct {
+		Theme string `json:"theme"`
+	} `json:"options"`
+}
+
+// TestSchema tests structs are stored as components and referenced.
+func TestSchema(t *testing.T) {
+	g := newSchemaGenerator()
+	ref := g.schema(&User{})
+	if !reflect.DeepEqual(ref, map[string]interface{}{"$ref": "#/components/schemas/User"}) {
+		t.Fatalf("openapi: wrong user ref got:%v", ref)
+	}
+
+	user := g.components["User"].(map[string]interface{})
+	props := user["properties"].(map[string]interface{})
+	want := map[string]interface{}{
+		"created": map[string]interface{}{"type": "string", "format": "date-time"},
+		"id":      map[string]interface{}{"type": "integer", "format": "int64"},
+		"name":    map[string]interface{}{"type": "string"},
+		"admin":   map[string]interface{}{"type": "boolean"},
+		"address": map[string]interface{}{"$ref": "#/components/schemas/Address"},
+		"tags":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"extra":   map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+		"avatar":  map[string]interface{}{"type": "string", "format": "byte"},
+		"nick":    map[string]interface{}{"type": "string"},
+		"options": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"theme": map[string]interface{}{"type": "string"}}, "required": []interface{}{"theme"}},
+	}
+	if !reflect.DeepEqual(props, want) {
+		t.Errorf("openapi: wrong user properties got:%v want:%v", props, want)
+	}
+	required := []interface{}{"id", "name", "address", "tags", "extra", "avatar", "options"}
+	if !reflect.DeepEqual(user["required"], required) {
+		t.Errorf("openapi: wrong required got:%v want:%v", user["required"], required)
+	}
+
+	address := g.components["Address"].(map[string]interface{})
+	if !reflect.DeepEqual(address["required"], []interface{}{"street"}) {
+		t.Errorf("openapi: wrong address required got:%v", address["required"])
+	}
+}
+
+// TestSchemaRecursive tests recursive types refer to their component.
+func TestSchemaRecursive(t *testing.T) {
+	g := newSchemaGenerator()
+	g.schema(Node{})
+
+	ref := map[string]interface{}{"$ref": "#/components/schemas/Node"}
+	node := g.components["Node"].(map[string]interface{})
+	props := node["properties"].(map[string]interface{})
+	if !reflect.DeepEqual(props["parent"], ref) || !reflect.DeepEqual(props["children"], map[string]interface{}{"type": "array", "items": ref}) {
+		t.Errorf("openapi: wrong recursive schema got:%v", props)
+	}
+	if len(g.components) != 1 {
+		t.Errorf("openapi: wrong components got:%v", g.components)
+	}
+}
+
+// TestDocument tests routes are documented with their operations, tags and metadata.
+func TestDocument(t *testing.T) {
+	m := mux.New()
+	spec := New(Info{Title: "Test", Version: "1.0"})
+	spec.Servers = []string{"https://example.com"}
+
+	spec.Describe(m.Get("/users/{id:int}", handler), Operation{Summary: "Show a user", Response: User{}, Query: []string{"fields"}})
+	spec.Describe(m.Post("/users", handler), Operation{Request: User{}, Response: User{}, Tags: []string{"users"}})
+	m.Get("/internal", handler).Tag(TagHidden)
+	m.Get("/legacy", handler).Tag(TagDeprecated)
+	m.Get("/old", handler).Deprecated(time.Now().Add(time.Hour), "/new")
+	m.Get("/described", handler).Meta("summary", "From meta").Meta("description", "Described by meta")
+	m.Add("/both", handler).Methods(http.MethodGet, http.MethodHead, http.MethodPut)
+
+	doc := spec.Document(m)
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("openapi: error encoding document %s", err)
+	}
+	var v struct {
+		OpenAPI string
+		Info    Info
+		Servers []struct{ URL string }
+		Paths   map[string]map[string]struct {
+			Summary     string
+			Description string
+			Deprecated  bool
+			Tags        []string
+			Parameters  []struct{ Name, In string }
+			RequestBody *struct{}
+		}
+		Components struct {
+			Schemas map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("openapi: error decoding document %s", err)
+	}
+
+	if v.OpenAPI != Version || v.Info.Title != "Test" || len(v.Servers) != 1 || v.Servers[0].URL != "https://example.com" {
+		t.Errorf("openapi: wrong document header got:%s %v %v", v.OpenAPI, v.Info, v.Servers)
+	}
+	if _, ok := v.Paths["/internal"]; ok {
+		t.Errorf("openapi: hidden route documented")
+	}
+	if !v.Paths["/legacy"]["get"].Deprecated || !v.Paths["/old"]["get"].Deprecated || v.Paths["/described"]["get"].Deprecated {
+		t.Errorf("openapi: wrong deprecated routes got:%v", v.Paths)
+	}
+	if op := v.Paths["/described"]["get"]; op.Summary != "From meta" || op.Description != "Described by meta" {
+		t.Errorf("openapi: wrong meta operation got:%+v", op)
+	}
+	// HEAD is implied by GET so is not documented
+	methods := v.Paths["/both"]
+	_, get := methods["get"]
+	_, put := methods["put"]
+	if len(methods) != 2 || !get || !put {
+		t.Errorf("openapi: wrong methods for /both got:%v", methods)
+	}
+
+	show := v.Paths["/users/{id}"]["get"]
+	if show.Summary != "Show a user" || len(show.Parameters) != 2 || show.Parameters[0].Name != "id" || show.Parameters[1].In != "query" || show.RequestBody != nil {
+		t.Errorf("openapi: wrong show operation got:%+v", show)
+	}
+	create := v.Paths["/users"]["post"]
+	if create.RequestBody == nil || len(create.Tags) != 1 {
+		t.Errorf("openapi: wrong create operation got:%+v", create)
+	}
+	if _, ok := v.Components.Schemas["User"]; !ok {
+		t.Errorf("openapi: missing user component got:%v", v.Components.Schemas)
+	}
+}
+
+// TestHandler tests the document is served as json or yaml by path.
+func TestHandler(t *testing.T) {
+	m := mux.New()
+	spec := New(Info{Title: "Test", Version: "1.0"})
+	m.Get("/openapi.json", spec.Handler(m))
+	m.Get("/openapi.yaml", spec.Handler(m))
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") || !json.Valid(w.Body.Bytes()) {
+		t.Errorf("openapi: wrong json document got:%s %s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/yaml") || !strings.Contains(w.Body.String(), `"openapi": "3.0.3"`) {
+		t.Errorf("openapi: wrong yaml document got:%s %s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
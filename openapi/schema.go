@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaGenerator generates json schemas from go types,
+// named struct types are stored once as components and referenced.
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+// newSchemaGenerator returns a new schemaGenerator
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{components: map[string]interface{}{}}
+}
+
+// schema returns the schema for the type of v.
+func (g *schemaGenerator) schema(v interface{}) map[string]interface{} {
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+// typeSchema returns the schema for type t.
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := g.components[t.Name()]; !ok {
+			// Store a placeholder first to allow recursive types
+			g.components[t.Name()] = map[string]interface{}{}
+			g.components[t.Name()] = g.structSchema(t)
+		}
+		return ref
+	}
+
+	// Interfaces and other types may hold any value
+	return map[string]interface{}{}
+}
+
+// structSchema returns an object schema with the exported fields of t,
+// named by their json tags.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []interface{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := f.Name
+		omitempty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, p := range parts[1:] {
+				omitempty = omitempty || p == "omitempty"
+			}
+		}
+
+		// Flatten embedded structs without a json name as encoding/json does
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			embedded := g.structSchema(f.Type)
+			for k, v := range embedded["properties"].(map[string]interface{}) {
+				properties[k] = v
+			}
+			continue
+		}
+
+		properties[name] = g.typeSchema(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
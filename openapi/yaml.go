@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// encodeYAML encodes the document as yaml. Documents contain only maps,
+// slices and scalars, so a minimal encoder suffices, strings are always
+// double quoted (which is valid yaml) to avoid ambiguity.
+func encodeYAML(doc map[string]interface{}) ([]byte, error) {
+	// Round trip through json to normalise the values in the document
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeYAML(&buf, v, 0)
+	return buf.Bytes(), nil
+}
+
+// writeYAML writes v to buf at the given indent level.
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			buf.WriteString(pad + strconv.Quote(k) + ":")
+			writeYAMLValue(buf, val[k], indent)
+		}
+	case []interface{}:
+		for _, item := range val {
+			buf.WriteString(pad + "-")
+			writeYAMLValue(buf, item, indent)
+		}
+	}
+}
+
+// writeYAMLValue writes a value following a key or list marker.
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, val, indent+1)
+	case []interface{}:
+		if len(val) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, val, indent+1)
+	case string:
+		buf.WriteString(" " + strconv.Quote(val) + "\n")
+	case nil:
+		buf.WriteString(" null\n")
+	default:
+		buf.WriteString(" " + fmt.Sprint(val) + "\n")
+	}
+}
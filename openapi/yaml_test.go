@@ -0,0 +1,73 @@
+package openapi
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestEncodeYAML tests documents are encoded as yaml with all strings quoted.
+func TestEncodeYAML(t *testing.T) {
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"count":   200,
+		"ratio":   0.5,
+		"enabled": true,
+		"nothing": nil,
+		"empty":   map[string]interface{}{},
+		"none":    []interface{}{},
+		"scalars": []interface{}{"yes", "no", "null", "~", "1.0", "key: value", "# comment", "- item", "line\nbreak", `say "hi"`, `back\slash`, "", "café"},
+		"list": []interface{}{
+			map[string]interface{}{"name": "id", "in": "path"},
+			[]interface{}{1, 2},
+		},
+		"a key: with colon": "value",
+	}
+
+	want := `"a key: with colon": "value"
+"count": 200
+"empty": {}
+"enabled": true
+"list":
+  -
+    "in": "path"
+    "name": "id"
+  -
+    - 1
+    - 2
+"none": []
+"nothing": null
+"openapi": "3.0.3"
+"ratio": 0.5
+"scalars":
+  - "yes"
+  - "no"
+  - "null"
+  - "~"
+  - "1.0"
+  - "key: value"
+  - "# comment"
+  - "- item"
+  - "line\nbreak"
+  - "say \"hi\""
+  - "back\\slash"
+  - ""
+  - "café"
+`
+
+	data, err := encodeYAML(doc)
+	if err != nil {
+		t.Fatalf("openapi: error encoding yaml %s", err)
+	}
+	if string(data) != want {
+		t.Errorf("openapi: wrong yaml got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Every line is a quoted key, a list item, or a list marker followed by a nested block
+	line := regexp.MustCompile(`^(  )*("([^"\\]|\\.)*":( .+)?|-( .+)?)$`)
+	for _, l := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if !line.MatchString(l) {
+			t.Errorf("openapi: invalid yaml line %q", l)
+		}
+	}
+}
@@ -15,27 +15,41 @@ import (
 )
 
 // Params returns a new set of params parsed from the request.
+// The route is read from the request context, set when the request
+// is served by a mux.
 func Params(r *http.Request) (*RequestParams, error) {
-	return ParamsWithMux(mux, r)
+	return ParamsWithMux(nil, r)
 }
 
-// ParamsWithMux returns params for a given mux and request
+// ParamsWithMux returns params for a given mux and request,
+// if the request has not been routed, m is used to find the route.
+// The params are also stored on the request under KeyParams.
 func ParamsWithMux(m *Mux, r *http.Request) (*RequestParams, error) {
+	params, err := parseParams(m, r)
+	if err != nil {
+		return nil, err
+	}
+	SetValue(r, KeyParams, params)
+	return params, nil
+}
+
+// parseParams parses the params for a request
+func parseParams(m *Mux, r *http.Request) (*RequestParams, error) {
 	params := &RequestParams{
 		Values: make(url.Values, 0),
 		Files:  make(map[string][]*multipart.FileHeader, 0),
 	}
 
 	// Find the route for request
-	route := mux.Match(r)
+	route := requestRoute(m, r)
 	if route == nil {
 		return nil, errors.New("mux: could not find route for request")
 	}
 
 	// Parse the request path params first
-	urlParams := route.Parse(r.URL.Path)
-	for k, v := range urlParams {
-		params.Set(k, []string{v})
+	var buf [8]Param
+	for _, p := range route.ParseInto(r.URL.Path, buf[:0]) {
+		params.Set(p.Key, []string{p.Value})
 	}
 
 	// Add query string params from request
@@ -44,18 +58,24 @@ func ParamsWithMux(m *Mux, r *http.Request) (*RequestParams, error) {
 		params.Add(k, v)
 	}
 
-	// If the body is empty, return now without error
-	if r.Body == nil {
+	// If the body is empty or left for the handler, return now without error
+	if r.Body == nil || route.IsRawBody() {
 		return params, nil
 	}
 
+	// Stop reading the body if the request is cancelled
+	if err := Cancelled(r); err != nil {
+		return nil, err
+	}
+	r.Body = ContextReader(r.Context(), r.Body)
+
 	// Parse based on content type
 	contentType := r.Header.Get("Content-Type")
 
 	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
 		err := r.ParseForm()
 		if err != nil {
-			return nil, err
+			return nil, readError(r, err)
 		}
 		for k, v := range r.Form {
 			params.Add(k, v)
@@ -64,7 +84,7 @@ func ParamsWithMux(m *Mux, r *http.Request) (*RequestParams, error) {
 	} else if strings.HasPrefix(contentType, "multipart/form-data") {
 		err := r.ParseMultipartForm(20 << 20) // 20MB
 		if err != nil {
-			return nil, err
+			return nil, readError(r, err)
 		}
 
 		// Add the form values
@@ -81,6 +101,21 @@ func ParamsWithMux(m *Mux, r *http.Request) (*RequestParams, error) {
 	return params, nil
 }
 
+// requestRoute returns the route stored in the request context,
+// or the route matched by m (or the default mux) if none is stored.
+func requestRoute(m *Mux, r *http.Request) Route {
+	if route := RouteFromContext(r); route != nil {
+		return route
+	}
+	if m == nil {
+		m = mux
+	}
+	if m == nil {
+		return nil
+	}
+	return m.Match(r)
+}
+
 // ParamsJSON returns a new set of params parsed from the request (json included, for testing).
 // This is a temporary method for testing json parsing, we should add this capability to Params()
 func ParamsJSON(r *http.Request) (*RequestParams, error) {
@@ -91,15 +126,15 @@ func ParamsJSON(r *http.Request) (*RequestParams, error) {
 	}
 
 	// Find the route for request
-	route := mux.Match(r)
+	route := requestRoute(nil, r)
 	if route == nil {
 		return nil, errors.New("mux: could not find route for request")
 	}
 
 	// Parse the request path params first
-	urlParams := route.Parse(r.URL.Path)
-	for k, v := range urlParams {
-		params.Set(k, []string{v})
+	var buf [8]Param
+	for _, p := range route.ParseInto(r.URL.Path, buf[:0]) {
+		params.Set(p.Key, []string{p.Value})
 	}
 
 	// Add query string params from request
@@ -108,18 +143,24 @@ func ParamsJSON(r *http.Request) (*RequestParams, error) {
 		params.Add(k, v)
 	}
 
-	// If the body is empty, return now without error
-	if r.Body == nil {
+	// If the body is empty or left for the handler, return now without error
+	if r.Body == nil || route.IsRawBody() {
 		return params, nil
 	}
 
+	// Stop reading the body if the request is cancelled
+	if err := Cancelled(r); err != nil {
+		return nil, err
+	}
+	r.Body = ContextReader(r.Context(), r.Body)
+
 	// Parse based on content type
 	contentType := r.Header.Get("Content-Type")
 
 	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
 		err := r.ParseForm()
 		if err != nil {
-			return nil, err
+			return nil, readError(r, err)
 		}
 		for k, v := range r.Form {
 			params.Add(k, v)
@@ -128,7 +169,7 @@ func ParamsJSON(r *http.Request) (*RequestParams, error) {
 	} else if strings.HasPrefix(contentType, "multipart/form-data") {
 		err := r.ParseMultipartForm(20 << 20) // 20MB
 		if err != nil {
-			return nil, err
+			return nil, readError(r, err)
 		}
 
 		// Add the form values
@@ -143,7 +184,7 @@ func ParamsJSON(r *http.Request) (*RequestParams, error) {
 	} else if strings.HasPrefix(contentType, "application/json") {
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
-			return params, err
+			return params, readError(r, err)
 		}
 
 		// If no body provided, return straight away
@@ -192,3 +192,33 @@ func TestPostMultipart(t *testing.T) {
 	}
 	// TODO: file is there, verify reading file contents compare with string above
 }
+
+// TestParamsContext tests params are read from the route in the request context,
+// rather than the default mux.
+func TestParamsContext(t *testing.T) {
+	var id int64
+	m2 := New()
+	m2.Get(`/pages/{id:\d+}`, func(w http.ResponseWriter, r *http.Request) error {
+		params, err := Params(r)
+		if err != nil {
+			return err
+		}
+		id = params.GetInt("id")
+		if RouteFromContext(r) == nil {
+			t.Errorf("params: no route in context")
+		}
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	m2.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pages/9", nil))
+	if w.Code != http.StatusOK || id != 9 {
+		t.Errorf("params: failed to parse params from context got:%d %d", w.Code, id)
+	}
+
+	// Params without a routed request uses the mux given
+	params, err := ParamsWithMux(m2, httptest.NewRequest(http.MethodGet, "/pages/3", nil))
+	if err != nil || params.GetInt("id") != 3 {
+		t.Errorf("params: failed to parse params with mux got:%v", err)
+	}
+}
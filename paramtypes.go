@@ -0,0 +1,67 @@
+package mux
+
+import "regexp"
+
+// paramType is a named type for params in patterns, such as {id:int}
+type paramType struct {
+	pattern string         // the pattern matched in routes
+	desc    string         // the description used in errors
+	re      *regexp.Regexp // matches complete values of the type
+}
+
+// paramTypes lists the types which may be used for params in patterns
+var paramTypes = map[string]*paramType{
+	"int":   newParamType(`-?\d+`, "an integer"),
+	"uint":  newParamType(`\d+`, "a positive integer"),
+	"float": newParamType(`-?\d+(?:\.\d+)?`, "a number"),
+	"bool":  newParamType(`true|false|1|0`, "true or false"),
+	"uuid":  newParamType(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, "a uuid"),
+}
+
+// newParamType returns a paramType for pattern
+func newParamType(pattern, desc string) *paramType {
+	return &paramType{pattern: pattern, desc: desc, re: regexp.MustCompile(`^(?:` + pattern + `)$`)}
+}
+
+// typedParam is a param with a type in a route pattern
+type typedParam struct {
+	index int // the index of the param in the pattern
+	name  string
+	kind  *paramType
+}
+
+// typedRoute is implemented by routes which may have typed params
+type typedRoute interface {
+	matchLoose(string) bool
+	checkParamTypes(string) error
+}
+
+// matchLoose returns true if path matches the route whatever the values of typed params.
+// It returns false if the route has no typed params.
+func (r *NaiveRoute) matchLoose(path string) bool {
+	return r.loose != nil && r.loose.MatchString(path)
+}
+
+// checkParamTypes returns a 400 StatusError for the first typed param in path
+// with a value of the wrong type, or nil if all are valid.
+func (r *NaiveRoute) checkParamTypes(path string) error {
+	if r.loose == nil {
+		return nil
+	}
+	matches := r.loose.FindStringSubmatch(path)
+	if matches == nil {
+		return nil
+	}
+	for _, p := range r.typed {
+		if p.index+1 < len(matches) && !p.kind.re.MatchString(matches[p.index+1]) {
+			return bindError(p.name, "must be "+p.kind.desc)
+		}
+	}
+	return nil
+}
+
+// matchLoose returns true if route matches path whatever the values of its typed params
+func matchLoose(route Route, path string) bool {
+	tr, ok := route.(typedRoute)
+	return ok && tr.matchLoose(path)
+}
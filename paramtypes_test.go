@@ -0,0 +1,57 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestParamTypes tests routes with typed params match only values of the type,
+// or reject them with a 400 through the ErrorHandler if ParamTypeErrors is set.
+func TestParamTypes(t *testing.T) {
+	m := New()
+	m.Get("/users/{id:int}", handler)
+	m.Get("/users/new", handler)
+	m.Get("/items/{id:uuid}/{ok:bool}", handler)
+
+	var errs []error
+	m.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		errs = append(errs, err)
+		w.WriteHeader(ErrorStatus(err))
+	}
+
+	tests := []struct {
+		path   string
+		strict int
+		loose  int
+	}{
+		{"/users/12", http.StatusOK, http.StatusOK},
+		{"/users/-3", http.StatusOK, http.StatusOK},
+		{"/users/new", http.StatusOK, http.StatusOK},
+		{"/users/abc", http.StatusNotFound, http.StatusBadRequest},
+		{"/items/0b4e7a0e-5d3a-4b3e-9f1a-2c6d8e9f0a1b/true", http.StatusOK, http.StatusOK},
+		{"/items/0b4e7a0e/true", http.StatusNotFound, http.StatusBadRequest},
+		{"/items/0b4e7a0e-5d3a-4b3e-9f1a-2c6d8e9f0a1b/yes", http.StatusNotFound, http.StatusBadRequest},
+		{"/other", http.StatusNotFound, http.StatusNotFound},
+	}
+
+	for _, typeErrors := range []bool{false, true} {
+		m.ParamTypeErrors = typeErrors
+		m.cache.reset()
+		for _, test := range tests {
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+			want := test.strict
+			if typeErrors {
+				want = test.loose
+			}
+			if w.Code != want {
+				t.Errorf("paramtypes: wrong status for %s (errors:%v) got:%d want:%d", test.path, typeErrors, w.Code, want)
+			}
+		}
+	}
+
+	if len(errs) == 0 || errs[len(errs)-1].Error() != "mux: param ok must be true or false" {
+		t.Errorf("paramtypes: wrong errors got:%v", errs)
+	}
+}
@@ -0,0 +1,61 @@
+package mux
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// preloadTypes maps file extensions to the as attribute for preload links
+var preloadTypes = map[string]string{
+	".css":   "style",
+	".js":    "script",
+	".mjs":   "script",
+	".woff":  "font",
+	".woff2": "font",
+	".ttf":   "font",
+	".otf":   "font",
+	".png":   "image",
+	".jpg":   "image",
+	".jpeg":  "image",
+	".gif":   "image",
+	".svg":   "image",
+	".webp":  "image",
+	".json":  "fetch",
+}
+
+// PreloadLink returns a Link header value to preload the asset at path p,
+// with the as attribute set from the file extension e.g.
+// </assets/app.css>; rel=preload; as=style
+// Values which are already formatted as a link are returned unchanged.
+func PreloadLink(p string) string {
+	if strings.HasPrefix(p, "<") {
+		return p
+	}
+
+	link := "<" + p + ">; rel=preload"
+	as, ok := preloadTypes[strings.ToLower(path.Ext(p))]
+	if ok {
+		link += "; as=" + as
+		// Fonts must be fetched with cors even on the same origin
+		if as == "font" {
+			link += "; crossorigin"
+		}
+	}
+	return link
+}
+
+// writePreloads adds Link headers for the preloads given, and if EarlyHints
+// is set and the client supports informational responses sends them immediately
+// as 103 Early Hints, so that the client may fetch them while the handler runs.
+// The Link headers are also sent with the final response.
+func (m *Mux) writePreloads(w http.ResponseWriter, r *http.Request, preloads []string) {
+	for _, link := range preloads {
+		w.Header().Add("Link", link)
+	}
+
+	// HTTP/1.0 clients do not support 1xx responses
+	if m.EarlyHints && r.ProtoAtLeast(1, 1) {
+		w.WriteHeader(http.StatusEarlyHints)
+	}
+}
@@ -0,0 +1,71 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+)
+
+// TestPreloadLink tests formatting of preload link headers.
+func TestPreloadLink(t *testing.T) {
+	tests := map[string]string{
+		"/assets/app.css":       "</assets/app.css>; rel=preload; as=style",
+		"/assets/app.js":        "</assets/app.js>; rel=preload; as=script",
+		"/assets/font.woff2":    "</assets/font.woff2>; rel=preload; as=font; crossorigin",
+		"/data":                 "</data>; rel=preload",
+		"</x.css>; rel=preload": "</x.css>; rel=preload",
+	}
+	for p, expected := range tests {
+		if link := PreloadLink(p); link != expected {
+			t.Errorf("preload: wrong link for %s got:%s", p, link)
+		}
+	}
+}
+
+// TestPreload tests preload headers are sent for routes with preloads.
+func TestPreload(t *testing.T) {
+	m := New()
+	m.Get("/", handler).Preload("/assets/app.css", "/assets/app.js")
+	m.Get("/plain", handler)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	m.ServeHTTP(w, r)
+	if len(w.Header()["Link"]) != 2 || w.Code != http.StatusOK {
+		t.Errorf("preload: wrong link headers got:%d %v", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/plain", nil)
+	m.ServeHTTP(w, r)
+	if len(w.Header()["Link"]) != 0 {
+		t.Errorf("preload: unexpected link headers got:%v", w.Header())
+	}
+
+	// Test early hints are sent as an informational response
+	m.EarlyHints = true
+	s := httptest.NewServer(m)
+	defer s.Close()
+
+	hints := 0
+	req, _ := http.NewRequest(http.MethodGet, s.URL+"/", nil)
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints && len(header["Link"]) == 2 {
+				hints++
+			}
+			return nil
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("preload: request failed:%s", err)
+	}
+	resp.Body.Close()
+	if hints != 1 || resp.StatusCode != http.StatusOK || len(resp.Header["Link"]) != 2 {
+		t.Errorf("preload: early hints not sent got hints:%d code:%d", hints, resp.StatusCode)
+	}
+}
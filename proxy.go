@@ -0,0 +1,104 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyOptions configures a reverse proxy route.
+type ProxyOptions struct {
+	// StripPrefix is removed from the request path before it is forwarded.
+	StripPrefix string
+
+	// PreserveHost sends the Host header of the original request to the target,
+	// by default the host of the target url is sent.
+	PreserveHost bool
+
+	// Rewrite is called to modify the outbound request after the defaults are applied.
+	Rewrite func(*httputil.ProxyRequest)
+
+	// ModifyResponse is called to modify the response from the target.
+	ModifyResponse func(*http.Response) error
+
+	// Transport is used to make requests to the target, defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// FlushInterval is the interval to flush the response while copying, a
+	// negative value flushes after every write. Streamed responses are always flushed.
+	FlushInterval time.Duration
+}
+
+// proxyMethods are the methods forwarded by proxy routes.
+var proxyMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// Proxy adds a route which forwards requests matching pattern to the target url,
+// setting X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto headers.
+// Websocket upgrades are passed through, and errors reaching the target are
+// sent to the ErrorHandler with status 502. For example to forward all
+// requests under /api to an internal service:
+// m.Proxy(`/api/{path:.*}`, "http://localhost:8080", mux.ProxyOptions{StripPrefix: "/api"})
+func (m *Mux) Proxy(pattern, targetURL string, opts ProxyOptions) (Route, error) {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := NewProxy(target, opts)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		m.handleError(w, r, NewStatusError(http.StatusBadGateway, err))
+	}
+
+	route := m.AddHandler(pattern, proxy.ServeHTTP)
+	return route.Methods(proxyMethods...), nil
+}
+
+// NewProxy returns a reverse proxy forwarding requests to target
+// with the options given.
+func NewProxy(target *url.URL, opts ProxyOptions) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// Strip the prefix before joining with the target path
+			if opts.StripPrefix != "" {
+				pr.Out.URL.Path = stripPrefix(pr.Out.URL.Path, opts.StripPrefix)
+				pr.Out.URL.RawPath = stripPrefix(pr.Out.URL.RawPath, opts.StripPrefix)
+			}
+
+			pr.SetURL(target)
+			pr.SetXForwarded()
+
+			if opts.PreserveHost {
+				pr.Out.Host = pr.In.Host
+			}
+
+			if opts.Rewrite != nil {
+				opts.Rewrite(pr)
+			}
+		},
+		ModifyResponse: opts.ModifyResponse,
+		Transport:      opts.Transport,
+		FlushInterval:  opts.FlushInterval,
+	}
+}
+
+// stripPrefix removes prefix from p, ensuring the result starts with /
+func stripPrefix(p, prefix string) string {
+	if p == "" {
+		return p
+	}
+	p = strings.TrimPrefix(p, prefix)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
@@ -0,0 +1,41 @@
+package mux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestProxy tests forwarding requests to a target server.
+func TestProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer target.Close()
+
+	m := New()
+	_, err := m.Proxy(`/api/{path:.*}`, target.URL+"/v1", ProxyOptions{StripPrefix: "/api"})
+	if err != nil {
+		t.Fatalf("proxy: error adding route:%s", err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/users/1", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "POST /v1/users/1 example.com" {
+		t.Errorf("proxy: unexpected response got:%d %s", w.Code, w.Body.String())
+	}
+
+	// Test errors reaching the target are reported as bad gateway
+	_, err = m.Proxy(`/down/{path:.*}`, "http://127.0.0.1:1", ProxyOptions{})
+	if err != nil {
+		t.Fatalf("proxy: error adding route:%s", err)
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/down/", nil)
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("proxy: expected bad gateway got:%d", w.Code)
+	}
+}
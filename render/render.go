@@ -0,0 +1,96 @@
+// Package render writes responses in common formats with the correct headers,
+// returning errors so that handlers may pass them on to the mux ErrorHandler.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/fragmenta/mux"
+	"github.com/fragmenta/mux/negotiate"
+)
+
+// Usage
+// func handleShow(w http.ResponseWriter, r *http.Request) error {
+//   ...
+//   return render.JSON(w, http.StatusOK, user)
+// }
+
+// Content types set by the render functions
+const (
+	ContentTypeJSON = "application/json; charset=utf-8"
+	ContentTypeXML  = "application/xml; charset=utf-8"
+	ContentTypeText = "text/plain; charset=utf-8"
+	ContentTypeHTML = "text/html; charset=utf-8"
+)
+
+// JSON writes v encoded as json with the status code given.
+// The value is encoded before writing, so if encoding fails
+// nothing is written and the error is returned.
+func JSON(w http.ResponseWriter, code int, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return write(w, code, ContentTypeJSON, append(data, '\n'))
+}
+
+// XML writes v encoded as xml (with the xml header) with the status code given.
+func XML(w http.ResponseWriter, code int, v interface{}) error {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return write(w, code, ContentTypeXML, append([]byte(xml.Header), data...))
+}
+
+// Text writes the string s as plain text with the status code given.
+func Text(w http.ResponseWriter, code int, s string) error {
+	return write(w, code, ContentTypeText, []byte(s))
+}
+
+// HTML executes the template t with data and writes the result with the status code given.
+// The template is executed before writing, so if execution fails
+// nothing is written and the error is returned.
+func HTML(w http.ResponseWriter, code int, t *template.Template, data interface{}) error {
+	if t == nil {
+		return errors.New("render: nil template")
+	}
+	var buf bytes.Buffer
+	err := t.Execute(&buf, data)
+	if err != nil {
+		return err
+	}
+	return write(w, code, ContentTypeHTML, buf.Bytes())
+}
+
+// Negotiate writes v as json, xml or text according to the Accept header of r,
+// defaulting to json if the client accepts any type. Text is written with fmt.Sprint.
+// If no format is acceptable, a 406 StatusError is returned.
+func Negotiate(w http.ResponseWriter, r *http.Request, code int, v interface{}) error {
+	w.Header().Add("Vary", "Accept")
+
+	switch negotiate.ContentType(r.Header.Get("Accept"), "application/json", "application/xml", "text/xml", "text/plain") {
+	case "application/json":
+		return JSON(w, code, v)
+	case "application/xml", "text/xml":
+		return XML(w, code, v)
+	case "text/plain":
+		return Text(w, code, fmt.Sprint(v))
+	}
+
+	return mux.NewStatusError(http.StatusNotAcceptable, fmt.Errorf("render: no acceptable format for %q", r.Header.Get("Accept")))
+}
+
+// write writes the headers and body
+func write(w http.ResponseWriter, code int, contentType string, body []byte) error {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	_, err := w.Write(body)
+	return err
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fragmenta/mux"
+)
+
+// user is rendered in tests
+type user struct {
+	Name string `json:"name" xml:"name"`
+}
+
+// String returns the name of the user
+func (u user) String() string {
+	return u.Name
+}
+
+// TestRender tests each format is written with its content type and status.
+func TestRender(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(`<p>{{.Name}}</p>`))
+
+	tests := []struct {
+		name        string
+		render      func(w http.ResponseWriter) error
+		code        int
+		contentType string
+		body        string
+	}{
+		{"json", func(w http.ResponseWriter) error { return JSON(w, http.StatusCreated, user{"alice"}) }, http.StatusCreated, ContentTypeJSON, "{\"name\":\"alice\"}\n"},
+		{"xml", func(w http.ResponseWriter) error { return XML(w, http.StatusOK, user{"alice"}) }, http.StatusOK, ContentTypeXML, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>alice</name></user>"},
+		{"text", func(w http.ResponseWriter) error { return Text(w, http.StatusAccepted, "hello") }, http.StatusAccepted, ContentTypeText, "hello"},
+		{"html", func(w http.ResponseWriter) error { return HTML(w, http.StatusNotFound, tmpl, user{"<b>"}) }, http.StatusNotFound, ContentTypeHTML, "<p>&lt;b&gt;</p>"},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		if err := tc.render(w); err != nil {
+			t.Errorf("render: %s unexpected error %s", tc.name, err)
+			continue
+		}
+		if w.Code != tc.code || w.Header().Get("Content-Type") != tc.contentType || w.Body.String() != tc.body {
+			t.Errorf("render: %s wrong response got:%d %s %q want:%d %s %q", tc.name, w.Code, w.Header().Get("Content-Type"), w.Body.String(), tc.code, tc.contentType, tc.body)
+		}
+	}
+}
+
+// TestRenderErrors tests nothing is written if encoding or execution fails.
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(w http.ResponseWriter) error
+	}{
+		{"json", func(w http.ResponseWriter) error { return JSON(w, http.StatusOK, make(chan int)) }},
+		{"xml", func(w http.ResponseWriter) error { return XML(w, http.StatusOK, map[string]string{"a": "b"}) }},
+		{"html", func(w http.ResponseWriter) error {
+			return HTML(w, http.StatusOK, template.Must(template.New("").Parse(`<p>{{.Missing}}</p>`)), user{})
+		}},
+		{"nil template", func(w http.ResponseWriter) error { return HTML(w, http.StatusOK, nil, nil) }},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		if err := tc.render(w); err == nil {
+			t.Errorf("render: %s no error", tc.name)
+		}
+		if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" || w.Code != http.StatusOK {
+			t.Errorf("render: %s wrote response on error got:%d %s %q", tc.name, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
+
+// TestNegotiate tests formats are chosen by the Accept header.
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", ContentTypeJSON, "{\"name\":\"alice\"}\n"},
+		{"*/*", ContentTypeJSON, "{\"name\":\"alice\"}\n"},
+		{"application/xml", ContentTypeXML, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>alice</name></user>"},
+		{"text/xml", ContentTypeXML, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>alice</name></user>"},
+		{"text/*", ContentTypeXML, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>alice</name></user>"},
+		{"text/plain, application/json;q=0.5", ContentTypeText, "alice"},
+		{"application/json;q=0, */*", ContentTypeXML, `<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>alice</name></user>"},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tc.accept)
+		if err := Negotiate(w, r, http.StatusOK, user{"alice"}); err != nil {
+			t.Errorf("render: unexpected error for %q %s", tc.accept, err)
+			continue
+		}
+		if w.Header().Get("Content-Type") != tc.contentType || w.Body.String() != tc.body {
+			t.Errorf("render: wrong response for %q got:%s %q want:%s %q", tc.accept, w.Header().Get("Content-Type"), w.Body.String(), tc.contentType, tc.body)
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("render: wrong vary for %q got:%s", tc.accept, w.Header().Get("Vary"))
+		}
+	}
+}
+
+// TestNegotiateNotAcceptable tests a 406 StatusError is returned if no format is acceptable.
+func TestNegotiateNotAcceptable(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "image/png")
+
+	err := Negotiate(w, r, http.StatusOK, user{"alice"})
+	var se *mux.StatusError
+	if !errors.As(err, &se) || se.Status != http.StatusNotAcceptable {
+		t.Errorf("render: wrong error got:%v want:406 StatusError", err)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("render: wrote body when not acceptable got:%q", w.Body.String())
+	}
+}
@@ -0,0 +1,132 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// MaxShadowBody is the maximum size of request body buffered for shadow handlers,
+// requests with larger bodies are not mirrored.
+var MaxShadowBody int64 = 1 << 20 // 1MB
+
+// MaxShadowRequests is the maximum number of shadow handlers running at once,
+// requests are not mirrored while this many are running.
+var MaxShadowRequests int64 = 100
+
+// shadowRequests is the number of shadow handlers running
+var shadowRequests atomic.Int64
+
+// Canary routes percent of requests matching the route to handler instead of
+// the route handler, so that a new implementation can be rolled out gradually.
+func (r *NaiveRoute) Canary(handler HandlerFunc, percent int) Route {
+	r.canary = handler
+	r.canaryPercent = percent
+	r.rollout = r.serveRollout
+	return r
+}
+
+// Shadow mirrors requests matching the route to handler, which runs
+// asynchronously after the route handler with its response discarded,
+// so that a new implementation can be tested against live traffic.
+func (r *NaiveRoute) Shadow(handler HandlerFunc) Route {
+	r.shadow = handler
+	r.rollout = r.serveRollout
+	return r
+}
+
+// serveRollout serves the request with the route or canary handler,
+// and mirrors it to the shadow handler if set.
+func (r *NaiveRoute) serveRollout(w http.ResponseWriter, req *http.Request) error {
+	var mirror *http.Request
+	if r.shadow != nil && acquireShadow() {
+		mirror = shadowRequest(req)
+		if mirror == nil {
+			shadowRequests.Add(-1)
+		}
+	}
+
+	handler := r.handler
+	if r.canary != nil && rand.Intn(100) < r.canaryPercent {
+		handler = r.canary
+	}
+	err := handler(w, req)
+
+	if mirror != nil {
+		go runShadow(r.shadow, mirror, r.pattern)
+	}
+	return err
+}
+
+// acquireShadow returns true if a shadow handler may run, false if too many are running
+func acquireShadow() bool {
+	if shadowRequests.Add(1) > MaxShadowRequests {
+		shadowRequests.Add(-1)
+		return false
+	}
+	return true
+}
+
+// shadowRequest returns a copy of req for a shadow handler, buffering the body
+// so that it may be read by both handlers. It returns nil if the body is too large.
+// The copy has its own values and log fields, so the shadow handler does not
+// overwrite the params or error recorded for the original request.
+func shadowRequest(req *http.Request) *http.Request {
+	// The shadow request must outlive the original request
+	mirror := req.Clone(context.WithoutCancel(req.Context()))
+	mirror = log.CopyFields(copyValues(mirror))
+	if req.Body == nil || req.Body == http.NoBody {
+		return mirror
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, MaxShadowBody+1))
+	// Restore the body read so far for the route handler
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	if err != nil || int64(len(body)) > MaxShadowBody {
+		return nil
+	}
+
+	mirror.Body = io.NopCloser(bytes.NewReader(body))
+	return mirror
+}
+
+// runShadow runs the shadow handler, discarding the response
+// and logging any errors or panics.
+func runShadow(h HandlerFunc, req *http.Request, pattern string) {
+	defer func() {
+		shadowRequests.Add(-1)
+		if p := recover(); p != nil {
+			log.Errorf("mux: panic in shadow handler for %s:%v", pattern, p)
+		}
+	}()
+	err := h(&discardWriter{header: make(http.Header)}, req)
+	if err != nil {
+		log.Errorf("mux: error in shadow handler for %s:%s", pattern, err)
+	}
+}
+
+// discardWriter is a ResponseWriter which discards the response
+type discardWriter struct {
+	header http.Header
+}
+
+// Header returns the response headers
+func (d *discardWriter) Header() http.Header {
+	return d.header
+}
+
+// Write discards b
+func (d *discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// WriteHeader discards the status
+func (d *discardWriter) WriteHeader(int) {}
@@ -0,0 +1,122 @@
+package mux
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// TestCanary tests a percentage of requests are sent to the canary handler.
+func TestCanary(t *testing.T) {
+	m := New()
+	primary, canary := 0, 0
+	m.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		primary++
+		return nil
+	}).Canary(func(w http.ResponseWriter, r *http.Request) error {
+		canary++
+		return nil
+	}, 20)
+
+	for i := 0; i < 1000; i++ {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	if primary+canary != 1000 || canary < 100 || canary > 300 {
+		t.Errorf("rollout: canary wrong proportion got:%d primary:%d", canary, primary)
+	}
+}
+
+// TestShadow tests requests are mirrored to the shadow handler with the body.
+func TestShadow(t *testing.T) {
+	m := New()
+	bodies := make(chan string, 1)
+	m.Post("/users", func(w http.ResponseWriter, r *http.Request) error {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+		return nil
+	}).Shadow(func(w http.ResponseWriter, r *http.Request) error {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+		bodies <- string(body)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name=bob")))
+	if w.Code != http.StatusOK || w.Body.String() != "name=bob" {
+		t.Errorf("rollout: primary response wrong got:%d %s", w.Code, w.Body.String())
+	}
+
+	select {
+	case body := <-bodies:
+		if body != "name=bob" {
+			t.Errorf("rollout: shadow body wrong got:%s", body)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("rollout: shadow handler not called")
+	}
+}
+
+// TestShadowIsolated tests shadow handlers do not overwrite the values of the
+// original request, and are not run when too many are in flight.
+func TestShadowIsolated(t *testing.T) {
+	m := New()
+	done := make(chan struct{})
+	release := make(chan struct{})
+	m.Post("/users", func(w http.ResponseWriter, r *http.Request) error {
+		_, err := Params(r)
+		return err
+	}).Shadow(func(w http.ResponseWriter, r *http.Request) error {
+		defer func() { done <- struct{}{} }()
+		<-release
+		params, _ := Params(r)
+		params.Values.Set("name", "shadow")
+		log.SetField(r, "shadow", true)
+		return errors.New("shadow failed")
+	})
+
+	var primary *http.Request
+	m.AddMiddleware(func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r = log.WithFields(r)
+			if primary == nil {
+				primary = r
+			}
+			h(w, r)
+		}
+	})
+
+	defer func(max int64) { MaxShadowRequests = max }(MaxShadowRequests)
+	MaxShadowRequests = 1
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name=bob"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		m.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	close(release)
+	<-done
+
+	// Only one shadow handler should run
+	select {
+	case <-done:
+		t.Errorf("rollout: shadow handlers not limited")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	params, _ := Value[*RequestParams](primary, KeyParams)
+	if params == nil || params.Get("name") != "bob" {
+		t.Errorf("rollout: shadow handler overwrote params got:%v", params)
+	}
+	if err, _ := Value[error](primary, KeyError); err != nil {
+		t.Errorf("rollout: shadow handler set error got:%v", err)
+	}
+	if log.GetField(primary, "shadow") != nil {
+		t.Errorf("rollout: shadow handler set log field")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // NewRoute returns a new Route of our default type.
@@ -35,10 +36,30 @@ type NaiveRoute struct {
 	methods    []string
 	paramNames []string
 	regexp     *regexp.Regexp
+	segments   []segment
+	preloads   []string
+	rawBody    bool
+	meta       map[string]interface{}
+	tags       []string
+	deprecated *Deprecation
+
+	// Typed params such as {id:int}, and a regexp matching any values for them
+	typed []typedParam
+	loose *regexp.Regexp
+
+	// Handlers for gradual rollouts, see Canary and Shadow
+	canary        HandlerFunc
+	canaryPercent int
+	shadow        HandlerFunc
+	rollout       HandlerFunc
 }
 
-// Handler returns our handlerfunc.
+// Handler returns our handlerfunc,
+// which also serves any canary or shadow handlers set.
 func (r *NaiveRoute) Handler() HandlerFunc {
+	if r.rollout != nil {
+		return r.rollout
+	}
 	return r.handler
 }
 
@@ -55,7 +76,7 @@ func (r *NaiveRoute) Setup(p string, h HandlerFunc) error {
 
 // Handle calls the handler with the writer and request.
 func (r *NaiveRoute) Handle(w http.ResponseWriter, req *http.Request) error {
-	return r.handler(w, req)
+	return r.Handler()(w, req)
 }
 
 // MatchMethod returns true if our list of methods contains method
@@ -83,6 +104,13 @@ func (r *NaiveRoute) MatchMaybe(path string) bool {
 // Match returns true if this route matches the path given.
 func (r *NaiveRoute) Match(path string) bool {
 
+	// If the pattern has only simple params, match segments without a regexp
+	if r.segments != nil {
+		var buf [8]Param
+		_, ok := matchSegments(r.segments, path, buf[:0])
+		return ok
+	}
+
 	// If we have a short pattern match, and we have a regexp, check against that
 	if r.regexp != nil {
 		return r.regexp.MatchString(path)
@@ -124,6 +152,81 @@ func (r *NaiveRoute) Methods(permitted ...string) Route {
 	return r
 }
 
+// Preload adds Link preload headers for these asset paths to responses,
+// paths may also be given as complete Link header values.
+func (r *NaiveRoute) Preload(paths ...string) Route {
+	for _, p := range paths {
+		r.preloads = append(r.preloads, PreloadLink(p))
+	}
+	return r
+}
+
+// Preloads returns the Link header values for assets to preload
+func (r *NaiveRoute) Preloads() []string {
+	return r.preloads
+}
+
+// RawBody marks the route as reading the request body itself,
+// so Params parses only path and query params and leaves the body unread.
+func (r *NaiveRoute) RawBody() Route {
+	r.rawBody = true
+	return r
+}
+
+// IsRawBody returns true if Params should not read the request body
+func (r *NaiveRoute) IsRawBody() bool {
+	return r.rawBody
+}
+
+// Meta sets metadata on the route under key, for use by middleware
+// and generators, for example route.Meta("timeout", 5*time.Second).
+func (r *NaiveRoute) Meta(key string, value interface{}) Route {
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[key] = value
+	return r
+}
+
+// Tag adds tags to the route, for example route.Tag("auth:public", "docs:hidden").
+func (r *NaiveRoute) Tag(tags ...string) Route {
+	for _, t := range tags {
+		if !HasTag(r, t) {
+			r.tags = append(r.tags, t)
+		}
+	}
+	return r
+}
+
+// Metadata returns the metadata set on the route, which should not be modified
+func (r *NaiveRoute) Metadata() map[string]interface{} {
+	return r.meta
+}
+
+// Tags returns the tags set on the route, which should not be modified
+func (r *NaiveRoute) Tags() []string {
+	return r.tags
+}
+
+// Deprecated marks the route as deprecated, to be removed at sunset (if not zero),
+// with link (if not empty) pointing to documentation for clients.
+func (r *NaiveRoute) Deprecated(sunset time.Time, link string) Route {
+	r.deprecated = &Deprecation{Sunset: sunset, Link: link}
+	return r
+}
+
+// Deprecation returns the deprecation for the route, or nil if it is not deprecated
+func (r *NaiveRoute) Deprecation() *Deprecation {
+	return r.deprecated
+}
+
+// AllowedMethods returns a copy of the methods allowed for this route
+func (r *NaiveRoute) AllowedMethods() []string {
+	methods := make([]string, len(r.methods))
+	copy(methods, r.methods)
+	return methods
+}
+
 // Pattern returns the string pattern for the route
 func (r *NaiveRoute) Pattern() string {
 	return r.pattern
@@ -140,25 +243,46 @@ func (r *NaiveRoute) Parse(path string) map[string]string {
 	// Set up our params map
 	params := make(map[string]string, 0)
 
-	// If called on a nil route, return empty params
+	var buf [8]Param
+	for _, p := range r.ParseInto(path, buf[:0]) {
+		params[p.Key] = p.Value
+	}
+
+	return params
+}
+
+// ParseInto parses this path and appends the URL params found to buf,
+// returning the extended slice. No params are appended if the path does not match.
+// Callers may pass a reused buffer to avoid allocations.
+func (r *NaiveRoute) ParseInto(path string, buf []Param) []Param {
+
+	// If called on a nil route, return no params
 	if r == nil || r.regexp == nil || len(r.paramNames) == 0 {
+		return buf
+	}
+
+	// Match segments without a regexp if possible
+	if r.segments != nil {
+		params, ok := matchSegments(r.segments, path, buf)
+		if !ok {
+			return buf
+		}
 		return params
 	}
 
-	// Find a set of matches, and for each match set the entry in our map.
+	// Find a set of matches, and for each match append a param
 	matches := r.regexp.FindStringSubmatch(path)
 
 	if matches != nil {
 		for i, key := range r.paramNames {
 			index := i + 1
 			if len(matches) > index {
-				value := matches[index]
-				params[key] = value
+				buf = append(buf, Param{Key: key, Value: matches[index]})
 			}
 		}
 	}
 
-	return params
+	return buf
 }
 
 // compileRegexp compiles our route format to a true regexp
@@ -182,7 +306,9 @@ func (r *NaiveRoute) compileRegexp() (err error) {
 	}
 
 	pattern := bytes.NewBufferString("^")
+	loose := bytes.NewBufferString("^")
 	end := 0
+	var patterns []string
 
 	// Walk through indexes two at a time
 	for i := 0; i < len(idxs); i += 2 {
@@ -194,18 +320,42 @@ func (r *NaiveRoute) compileRegexp() (err error) {
 			return fmt.Errorf("Missing name or pattern in %s", raw)
 		}
 
+		// Replace types such as int with their pattern, params of any value match loosely
+		loosePart := parts[1]
+		if kind, ok := paramTypes[parts[1]]; ok {
+			r.typed = append(r.typed, typedParam{index: len(r.paramNames), name: parts[0], kind: kind})
+			parts[1] = kind.pattern
+			loosePart = `[^/]+`
+		}
+
 		// Add the name to params in order of finding
 		r.paramNames = append(r.paramNames, parts[0])
+		patterns = append(patterns, parts[1])
 
 		// Add the real regexp
 		fmt.Fprintf(pattern, "%s(%s)", regexp.QuoteMeta(raw), parts[1])
+		fmt.Fprintf(loose, "%s(%s)", regexp.QuoteMeta(raw), loosePart)
 
 	}
 	// Add the remaining pattern
 	pattern.WriteString(regexp.QuoteMeta(r.pattern[end:]))
 	r.regexp, err = regexp.Compile(pattern.String())
+	if err != nil {
+		return err
+	}
+
+	if len(r.typed) > 0 {
+		loose.WriteString(regexp.QuoteMeta(r.pattern[end:]))
+		r.loose, err = regexp.Compile(loose.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Precompile segments so that simple patterns need not use the regexp
+	r.segments = compileSegments(r.pattern, idxs, r.paramNames, patterns)
 
-	return err
+	return nil
 }
 
 // findBraces returns the first level curly brace indices from a string.
@@ -3,6 +3,7 @@ package mux
 import (
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -275,3 +276,73 @@ func TestRouteRegexpParsing(t *testing.T) {
 	}
 
 }
+
+// TestRouteSegments tests matching simple patterns without a regexp gives the same results as the regexp.
+func TestRouteSegments(t *testing.T) {
+	patterns := []string{
+		`/users/{id:\d+}`,
+		`/users/{id:[0-9]+}/update`,
+		`/users/{name:[^/]+}/posts/{id:\d+}`,
+		`/files/{path:.*}`,
+		`/{path:.*}`,
+		`/pages/{id:\d+}.json`,
+		`/tags/{name:[^/]+}.json`,
+		`/ids/{a:\d+}{b:\d+}`,
+		`/v/{a:\d+}1`,
+	}
+	paths := []string{
+		"/users/1", "/users/1-slug", "/users/", "/users/abc", "/users/12/update", "/users/12/updated",
+		"/users/bob/posts/3", "/users/bob/posts/x", "/users//posts/3", "/files/a/b/c.txt", "/files/",
+		"/files", "/x/y\nz", "/pages/3.json", "/pages/3.xml", "/tags/go.json", "/tags/go", "/ids/1234",
+		"/v/111", "/v/1",
+	}
+
+	for _, pattern := range patterns {
+		route, err := NewRoute(pattern, handler)
+		if err != nil {
+			t.Fatalf("route: error creating route:%s", err)
+		}
+		r := route.(*PrefixRoute)
+		for _, path := range paths {
+			want := r.regexp.MatchString(path)
+			if r.Match(path) != want {
+				t.Errorf("route: %s match %s got:%v want:%v", pattern, path, !want, want)
+			}
+
+			var values []string
+			for _, p := range r.ParseInto(path, nil) {
+				values = append(values, p.Value)
+			}
+			matches := r.regexp.FindStringSubmatch(path)
+			if len(matches) > 0 && strings.Join(matches[1:], ",") != strings.Join(values, ",") {
+				t.Errorf("route: %s parse %s got:%v want:%v", pattern, path, values, matches[1:])
+			}
+		}
+	}
+
+	// Check which patterns avoid the regexp
+	simple, _ := NewRoute(`/users/{name:[^/]+}/posts/{id:\d+}`, handler)
+	if simple.(*PrefixRoute).segments == nil {
+		t.Errorf("route: simple pattern not compiled to segments")
+	}
+	tagged, _ := NewRoute(`/tags/{name:[^/]+}.json`, handler)
+	if tagged.(*PrefixRoute).segments != nil {
+		t.Errorf("route: pattern compiled to segments which requires a regexp")
+	}
+}
+
+// TestParseIntoAllocs tests ParseInto with a buffer does not allocate.
+func TestParseIntoAllocs(t *testing.T) {
+	r, _ := NewRoute(`/users/{name:[^/]+}/posts/{id:\d+}`, handler)
+	buf := make([]Param, 0, 4)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = r.ParseInto("/users/bob/posts/3", buf[:0])
+		r.Match("/users/bob/posts/3")
+	})
+	if allocs > 0 {
+		t.Errorf("route: ParseInto allocated got:%v", allocs)
+	}
+	if len(buf) != 2 || buf[0].Value != "bob" || buf[1].Value != "3" {
+		t.Errorf("route: ParseInto wrong params got:%v", buf)
+	}
+}
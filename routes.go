@@ -0,0 +1,154 @@
+package mux
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fragmenta/mux/negotiate"
+)
+
+// RouteInfo describes a route for display by PrintRoutes and the routes debug handler.
+type RouteInfo struct {
+	Methods    []string               `json:"methods"`
+	Pattern    string                 `json:"pattern"`
+	Handler    string                 `json:"handler"`
+	Middleware []string               `json:"middleware"`
+	Tags       []string               `json:"tags,omitempty"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+}
+
+// RouteInfo returns descriptions of the routes on the mux in the order they are evaluated.
+// Middleware is listed in the order it is applied to requests.
+func (m *Mux) RouteInfo() []RouteInfo {
+	middleware := m.middlewareNames()
+
+	var info []RouteInfo
+	for _, route := range m.routes {
+		name, ok := m.handlerNames[route]
+		if !ok {
+			name = funcName(route.Handler())
+		}
+		info = append(info, RouteInfo{
+			Methods:    route.AllowedMethods(),
+			Pattern:    route.Pattern(),
+			Handler:    name,
+			Middleware: middleware,
+			Tags:       route.Tags(),
+			Meta:       route.Metadata(),
+		})
+	}
+	return info
+}
+
+// PrintRoutes writes an aligned table of the routes on the mux to w,
+// listing the methods, pattern, handler name, middleware and tags for each route.
+func (m *Mux) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHODS\tPATTERN\tHANDLER\tMIDDLEWARE\tTAGS")
+	for _, r := range m.RouteInfo() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", strings.Join(r.Methods, ","), r.Pattern, r.Handler, strings.Join(r.Middleware, ","), strings.Join(r.Tags, ","))
+	}
+	return tw.Flush()
+}
+
+// RouteFor returns the route for the request, from the request context if it
+// has been routed, or by matching against the routes on the mux if not.
+// Middleware may use this to read route metadata before the handler runs.
+func (m *Mux) RouteFor(r *http.Request) Route {
+	return requestRoute(m, r)
+}
+
+// HasTag returns true if the route is not nil and has the tag given.
+func HasTag(route Route, tag string) bool {
+	if route == nil {
+		return false
+	}
+	for _, t := range route.Tags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteMeta returns the metadata for key on the route if it is set and of type T.
+func RouteMeta[T any](route Route, key string) (T, bool) {
+	var zero T
+	if route == nil {
+		return zero, false
+	}
+	t, ok := route.Metadata()[key].(T)
+	return t, ok
+}
+
+// AddRoutesDebug adds a route at pattern (usually /_routes) which lists the
+// routes on the mux as html, or as json if requested with ?format=json
+// or an Accept header of application/json. The route only responds
+// if DebugRoutes is set on the mux, otherwise it is treated as not found.
+func (m *Mux) AddRoutesDebug(pattern string) Route {
+	return m.Get(pattern, m.handleRoutesDebug)
+}
+
+// routesTemplate renders the routes debug page
+var routesTemplate = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Routes</title></head>
+<body>
+<h1>Routes</h1>
+<table>
+<tr><th>Methods</th><th>Pattern</th><th>Handler</th><th>Middleware</th><th>Tags</th></tr>
+{{range .}}<tr><td>{{range $i, $m := .Methods}}{{if $i}},{{end}}{{$m}}{{end}}</td><td><code>{{.Pattern}}</code></td><td>{{.Handler}}</td><td>{{range $i, $m := .Middleware}}{{if $i}},{{end}}{{$m}}{{end}}</td><td>{{range $i, $t := .Tags}}{{if $i}},{{end}}{{$t}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// handleRoutesDebug renders the route table as html or json
+func (m *Mux) handleRoutesDebug(w http.ResponseWriter, r *http.Request) error {
+	if !m.DebugRoutes {
+		return m.FileHandler(w, r)
+	}
+
+	info := m.RouteInfo()
+
+	if r.URL.Query().Get("format") == "json" || negotiate.ContentType(r.Header.Get("Accept"), "text/html", "application/json") == "application/json" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		return json.NewEncoder(w).Encode(info)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return routesTemplate.Execute(w, info)
+}
+
+// middlewareNames returns the names of middleware in the order applied
+func (m *Mux) middlewareNames() []string {
+	names := []string{}
+	for i := len(m.handlerFuncs) - 1; i >= 0; i-- {
+		names = append(names, funcName(m.handlerFuncs[i]))
+	}
+	return names
+}
+
+// funcName returns the short name (package.Func) of a function
+func funcName(f interface{}) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestPrintRoutes tests printing the route table.
+func TestPrintRoutes(t *testing.T) {
+	m := New()
+	m.AddMiddleware(logMiddleware)
+	m.Get("/", handler)
+	m.Post(`/users/{id:\d+}/update`, updateHandler)
+	m.AddHandler("/std", func(w http.ResponseWriter, r *http.Request) {})
+
+	var b bytes.Buffer
+	err := m.PrintRoutes(&b)
+	if err != nil {
+		t.Fatalf("routes: error printing routes:%s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("routes: wrong number of lines got:%s", b.String())
+	}
+	if !strings.Contains(lines[2], "POST") || !strings.Contains(lines[2], "mux.updateHandler") || !strings.Contains(lines[2], "mux.logMiddleware") {
+		t.Errorf("routes: wrong route line got:%s", lines[2])
+	}
+	if strings.Contains(lines[3], "AddHandler") {
+		t.Errorf("routes: wrong handler name for std handler got:%s", lines[3])
+	}
+}
+
+// TestRoutesDebug tests the routes debug handler is guarded by DebugRoutes.
+func TestRoutesDebug(t *testing.T) {
+	m := New()
+	m.AddRoutesDebug("/_routes")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_routes", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("routes: debug routes served without flag got:%d", w.Code)
+	}
+
+	m.DebugRoutes = true
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_routes?format=json", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"pattern":"/_routes"`) {
+		t.Errorf("routes: debug routes failed got:%d %s", w.Code, w.Body.String())
+	}
+
+	// The format is negotiated with the Accept header
+	accepts := map[string]string{
+		"application/json":                  "application/json",
+		"application/json;q=0.5, text/html": "text/html",
+		"text/html, application/json;q=0":   "text/html",
+		"text/*;q=0.1, application/*;q=0.9": "application/json",
+		"":                                  "text/html",
+	}
+	for accept, want := range accepts {
+		r := httptest.NewRequest(http.MethodGet, "/_routes", nil)
+		r.Header.Set("Accept", accept)
+		w = httptest.NewRecorder()
+		m.ServeHTTP(w, r)
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), want) {
+			t.Errorf("routes: wrong format for %q got:%s want:%s", accept, w.Header().Get("Content-Type"), want)
+		}
+	}
+}
+
+// TestRouteMeta tests route metadata and tags are available to middleware and listings.
+func TestRouteMeta(t *testing.T) {
+	m := New()
+	m.Get("/public", handler).Tag("auth:public", "docs:hidden", "auth:public").Meta("timeout", 5)
+	m.Get("/private", handler)
+
+	public := false
+	m.AddMiddleware(func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			route := m.RouteFor(r)
+			public = HasTag(route, "auth:public")
+			if timeout, ok := RouteMeta[int](route, "timeout"); public && (!ok || timeout != 5) {
+				t.Errorf("routes: wrong meta got:%d", timeout)
+			}
+			h(w, r)
+		}
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/public", nil))
+	if !public {
+		t.Errorf("routes: tag not found for public route")
+	}
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/private", nil))
+	if public {
+		t.Errorf("routes: tag found for private route")
+	}
+	if _, ok := RouteMeta[string](m.Routes()[0], "timeout"); ok {
+		t.Errorf("routes: meta returned with wrong type")
+	}
+
+	info := m.RouteInfo()
+	if len(info[0].Tags) != 2 || info[0].Meta["timeout"] != 5 {
+		t.Errorf("routes: wrong route info got:%v %v", info[0].Tags, info[0].Meta)
+	}
+}
@@ -0,0 +1,121 @@
+package mux
+
+import "strings"
+
+// Param is a single named url param parsed from a path.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// segmentKind is the kind of param which follows the literal in a segment
+type segmentKind int
+
+const (
+	// segmentLiteral has no param, and is only used for the final segment
+	segmentLiteral segmentKind = iota
+	// segmentDigits matches \d+ or [0-9]+
+	segmentDigits
+	// segmentPart matches [^/]+
+	segmentPart
+	// segmentRest matches .*
+	segmentRest
+)
+
+// segment is a literal prefix followed by a param of kind
+type segment struct {
+	literal string
+	key     string
+	kind    segmentKind
+}
+
+// segmentKinds lists the param patterns which may be matched without a regexp
+var segmentKinds = map[string]segmentKind{
+	`\d+`:    segmentDigits,
+	`[0-9]+`: segmentDigits,
+	`[^/]+`:  segmentPart,
+	`.*`:     segmentRest,
+}
+
+// compileSegments returns segments for a pattern given the indices of its params
+// and their names and patterns, or nil if the pattern requires a regexp.
+// Segments are only returned where greedy matching gives the same result as the regexp
+// (which is anchored only at the start of the path), so that behaviour is unchanged.
+func compileSegments(pattern string, idxs []int, names, patterns []string) []segment {
+	var segments []segment
+	end := 0
+	for i := 0; i < len(idxs); i += 2 {
+		kind, ok := segmentKinds[patterns[i/2]]
+		if !ok {
+			return nil
+		}
+		segments = append(segments, segment{literal: pattern[end:idxs[i]], key: names[i/2], kind: kind})
+		end = idxs[i+1]
+	}
+	segments = append(segments, segment{literal: pattern[end:]})
+
+	// Check that each param cannot consume the start of the literal following it
+	for i := 0; i < len(segments)-1; i++ {
+		next := segments[i+1].literal
+		last := i == len(segments)-2 && next == ""
+		if last {
+			continue
+		}
+		if next == "" {
+			return nil // adjacent params
+		}
+		switch segments[i].kind {
+		case segmentDigits:
+			if next[0] >= '0' && next[0] <= '9' {
+				return nil
+			}
+		case segmentPart:
+			if next[0] != '/' {
+				return nil
+			}
+		case segmentRest:
+			return nil
+		}
+	}
+
+	return segments
+}
+
+// matchSegments matches path against segments, appending params to buf.
+// Like the regexp it replaces, the match is anchored only at the start of path.
+func matchSegments(segments []segment, path string, buf []Param) ([]Param, bool) {
+	for _, s := range segments {
+		if !strings.HasPrefix(path, s.literal) {
+			return buf, false
+		}
+		path = path[len(s.literal):]
+
+		n := 0
+		switch s.kind {
+		case segmentLiteral:
+			continue
+		case segmentDigits:
+			for n < len(path) && path[n] >= '0' && path[n] <= '9' {
+				n++
+			}
+		case segmentPart:
+			n = strings.IndexByte(path, '/')
+			if n < 0 {
+				n = len(path)
+			}
+		case segmentRest:
+			// . does not match newlines in a regexp
+			n = strings.IndexByte(path, '\n')
+			if n < 0 {
+				n = len(path)
+			}
+		}
+		if n == 0 && s.kind != segmentRest {
+			return buf, false
+		}
+
+		buf = append(buf, Param{Key: s.key, Value: path[:n]})
+		path = path[n:]
+	}
+	return buf, true
+}
@@ -0,0 +1,81 @@
+package serve
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fragmenta/mux"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// AutocertConfig represents the config for serving with certificates from Let's Encrypt
+type AutocertConfig struct {
+	Domains   []string // The domains certificates may be issued for
+	CacheDir  string   // The directory certificates are cached in
+	Email     string   // The contact email for the account (optional)
+	HTTPSAddr string   // The address to serve https on, defaults to :443
+	HTTPAddr  string   // The address to redirect http from, defaults to :80
+}
+
+// NewAutocertManager returns an autocert.Manager accepting the Let's Encrypt TOS
+// for the domains in config, which caches certificates in config.CacheDir.
+func NewAutocertManager(config AutocertConfig) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.Domains...),
+		Cache:      autocert.DirCache(config.CacheDir),
+		Email:      config.Email,
+	}
+}
+
+// ListenAndServeAutocert serves handler over https with certificates issued
+// and renewed automatically by Let's Encrypt, and serves a listener on http
+// which answers HTTP-01 challenges and redirects all other requests to https.
+// It blocks until the server is shut down by a signal.
+func ListenAndServeAutocert(handler http.Handler, config AutocertConfig) error {
+	s, err := newAutocertServer(handler, config)
+	if err != nil {
+		return err
+	}
+
+	// Certificates are supplied by the TLSConfig
+	return s.ListenAndServeTLS("", "")
+}
+
+// newAutocertServer returns a server for handler using certificates from an autocert.Manager,
+// with a secondary server on http for challenges and redirects.
+func newAutocertServer(handler http.Handler, config AutocertConfig) (*Server, error) {
+	if len(config.Domains) == 0 || config.CacheDir == "" {
+		return nil, errors.New("serve: autocert requires domains and a cache dir")
+	}
+	if config.HTTPSAddr == "" {
+		config.HTTPSAddr = ":443"
+	}
+	if config.HTTPAddr == "" {
+		config.HTTPAddr = ":80"
+	}
+
+	manager := NewAutocertManager(config)
+
+	s := New(handler, DefaultConfig(config.HTTPSAddr))
+	s.Server.TLSConfig = manager.TLSConfig()
+
+	// A nil fallback redirects all requests other than challenges to https
+	s.AddSecondary(&http.Server{
+		Addr:              config.HTTPAddr,
+		Handler:           manager.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	})
+
+	return s, nil
+}
+
+// AddChallengeRoute registers a route on m answering HTTP-01 challenges with manager,
+// for use when the mux itself serves plain http rather than the redirect listener.
+func AddChallengeRoute(m *mux.Mux, manager *autocert.Manager) mux.Route {
+	challenges := manager.HTTPHandler(http.NotFoundHandler())
+	return m.AddHandler("/.well-known/acme-challenge/{token:[^/]+}", challenges.ServeHTTP)
+}
@@ -0,0 +1,108 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fragmenta/mux"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TestAutocertManager tests the manager is configured with the domains and cache dir.
+func TestAutocertManager(t *testing.T) {
+	config := AutocertConfig{Domains: []string{"example.com", "www.example.com"}, CacheDir: t.TempDir(), Email: "admin@example.com"}
+	manager := NewAutocertManager(config)
+
+	if cache, ok := manager.Cache.(autocert.DirCache); !ok || string(cache) != config.CacheDir {
+		t.Errorf("autocert: wrong cache got:%v want:%s", manager.Cache, config.CacheDir)
+	}
+	if manager.Email != config.Email || !manager.Prompt("https://example.com/terms") {
+		t.Errorf("autocert: wrong account config got:%s", manager.Email)
+	}
+
+	tests := map[string]bool{
+		"example.com":     true,
+		"www.example.com": true,
+		"api.example.com": false,
+		"example.org":     false,
+	}
+	for host, allowed := range tests {
+		if err := manager.HostPolicy(context.Background(), host); (err == nil) != allowed {
+			t.Errorf("autocert: wrong host policy for %s got:%v", host, err)
+		}
+	}
+}
+
+// TestAutocertServer tests the https server uses the manager for certificates,
+// and the http server answers challenges and redirects other requests to https.
+func TestAutocertServer(t *testing.T) {
+	if _, err := newAutocertServer(http.NotFoundHandler(), AutocertConfig{CacheDir: t.TempDir()}); err == nil {
+		t.Errorf("autocert: no error without domains")
+	}
+	if _, err := newAutocertServer(http.NotFoundHandler(), AutocertConfig{Domains: []string{"example.com"}}); err == nil {
+		t.Errorf("autocert: no error without cache dir")
+	}
+
+	dir := t.TempDir()
+	s, err := newAutocertServer(http.NotFoundHandler(), AutocertConfig{Domains: []string{"example.com"}, CacheDir: dir})
+	if err != nil {
+		t.Fatalf("autocert: error creating server %s", err)
+	}
+	if s.Server.Addr != ":443" || s.Server.TLSConfig == nil || s.Server.TLSConfig.GetCertificate == nil {
+		t.Errorf("autocert: wrong https server got:%s %v", s.Server.Addr, s.Server.TLSConfig)
+	}
+	if len(s.secondary) != 1 || s.secondary[0].Addr != ":80" {
+		t.Fatalf("autocert: wrong http server got:%v", s.secondary)
+	}
+
+	// Challenge tokens are read from the cache dir
+	if err := os.WriteFile(filepath.Join(dir, "token+http-01"), []byte("token.key"), 0600); err != nil {
+		t.Fatalf("autocert: error writing token %s", err)
+	}
+
+	tests := []struct {
+		method   string
+		url      string
+		code     int
+		location string
+		body     string
+	}{
+		{http.MethodGet, "http://example.com/pages?id=1", http.StatusFound, "https://example.com/pages?id=1", ""},
+		{http.MethodHead, "http://example.com/", http.StatusFound, "https://example.com/", ""},
+		{http.MethodPost, "http://example.com/pages", http.StatusBadRequest, "", ""},
+		{http.MethodGet, "http://example.com/.well-known/acme-challenge/token", http.StatusOK, "", "token.key"},
+		{http.MethodGet, "http://example.com/.well-known/acme-challenge/missing", http.StatusNotFound, "", ""},
+		{http.MethodGet, "http://example.org/.well-known/acme-challenge/token", http.StatusForbidden, "", ""},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		s.secondary[0].Handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.url, nil))
+		if w.Code != tc.code || w.Header().Get("Location") != tc.location {
+			t.Errorf("autocert: wrong response for %s %s got:%d %s", tc.method, tc.url, w.Code, w.Header().Get("Location"))
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("autocert: wrong body for %s got:%s", tc.url, w.Body.String())
+		}
+	}
+}
+
+// TestAddChallengeRoute tests challenges are answered by a route on the mux.
+func TestAddChallengeRoute(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token+http-01"), []byte("token.key"), 0600); err != nil {
+		t.Fatalf("autocert: error writing token %s", err)
+	}
+	m := mux.New()
+	AddChallengeRoute(m, NewAutocertManager(AutocertConfig{Domains: []string{"example.com"}, CacheDir: dir}))
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/acme-challenge/token", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "token.key" {
+		t.Errorf("autocert: wrong challenge response got:%d %s", w.Code, w.Body.String())
+	}
+}
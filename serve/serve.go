@@ -0,0 +1,214 @@
+// Package serve wraps http.Server with sensible timeouts,
+// and shuts down gracefully on SIGINT or SIGTERM, draining in-flight requests.
+package serve
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// Usage
+// m := mux.New()
+// ...
+// err := serve.ListenAndServe(":3000", m)
+
+// Config represents the config for a Server
+type Config struct {
+	Addr              string        // The address to listen on e.g. :3000
+	ReadTimeout       time.Duration // Maximum duration for reading the entire request
+	ReadHeaderTimeout time.Duration // Maximum duration for reading request headers
+	WriteTimeout      time.Duration // Maximum duration before timing out writes of the response
+	IdleTimeout       time.Duration // Maximum duration to wait for the next request on keep-alive
+	ShutdownTimeout   time.Duration // Grace period for in-flight requests on shutdown
+	Signals           []os.Signal   // Signals which trigger shutdown, defaults to SIGINT and SIGTERM
+
+	// H2C serves HTTP/2 over cleartext connections (with prior knowledge) as well as HTTP/1,
+	// this is required by gRPC and some proxies and load balancers which terminate tls.
+	H2C bool
+
+	// HTTP2 tunes HTTP/2 parameters such as MaxConcurrentStreams, if nil defaults are used.
+	HTTP2 *http.HTTP2Config
+}
+
+// DefaultConfig returns a config with timeouts suitable for serving public traffic.
+// The WriteTimeout of 60s also limits streaming responses such as SSE,
+// handlers serving them should call LongLived to clear the write deadline.
+func DefaultConfig(addr string) Config {
+	return Config{
+		Addr:              addr,
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   30 * time.Second,
+		Signals:           []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+}
+
+// ListenAndServe serves handler on addr with the default config,
+// blocking until the server is shut down by a signal.
+func ListenAndServe(addr string, handler http.Handler) error {
+	return New(handler, DefaultConfig(addr)).ListenAndServe()
+}
+
+// ListenAndServeH2C serves handler on addr with the default config over both
+// HTTP/1 and HTTP/2 cleartext, blocking until the server is shut down by a signal.
+func ListenAndServeH2C(addr string, handler http.Handler) error {
+	config := DefaultConfig(addr)
+	config.H2C = true
+	return New(handler, config).ListenAndServe()
+}
+
+// Server wraps an http.Server, shutting it down gracefully on signals.
+type Server struct {
+	// Server is the underlying http server, it may be modified before serving.
+	Server *http.Server
+
+	// Tracker counts the requests in flight, and drains them on shutdown.
+	Tracker *Tracker
+
+	// secondary servers are started and shut down alongside Server
+	secondary []*http.Server
+
+	config Config
+}
+
+// New returns a new server for handler with config.
+func New(handler http.Handler, config Config) *Server {
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = 30 * time.Second
+	}
+	if len(config.Signals) == 0 {
+		config.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	tracker := NewTracker()
+	s := &Server{
+		Server: &http.Server{
+			Addr:              config.Addr,
+			Handler:           tracker.Handler(handler),
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		},
+		Tracker: tracker,
+		config:  config,
+	}
+
+	if config.HTTP2 != nil {
+		s.Server.HTTP2 = config.HTTP2
+	}
+
+	if config.H2C {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		s.Server.Protocols = protocols
+	}
+
+	return s
+}
+
+// ListenAndServe listens on the config address and serves requests
+// until a shutdown signal is received, then drains in-flight requests.
+func (s *Server) ListenAndServe() error {
+	return s.run(s.Server.ListenAndServe)
+}
+
+// ListenAndServeTLS listens on the config address and serves requests over tls
+// until a shutdown signal is received, then drains in-flight requests.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	return s.run(func() error {
+		return s.Server.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// Serve serves requests on l until a shutdown signal is received,
+// then drains in-flight requests.
+func (s *Server) Serve(l net.Listener) error {
+	return s.run(func() error {
+		return s.Server.Serve(l)
+	})
+}
+
+// Shutdown stops accepting connections, notifies long-lived connections,
+// and waits for in-flight requests and long-lived connections to finish,
+// or for the ShutdownTimeout to expire.
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	s.Server.SetKeepAlivesEnabled(false)
+	drained := make(chan error, 1)
+	go func() {
+		drained <- s.Tracker.Drain(ctx)
+	}()
+
+	for _, secondary := range s.secondary {
+		secondary.Shutdown(ctx)
+	}
+	err := s.Server.Shutdown(ctx)
+	if drainErr := <-drained; err == nil {
+		err = drainErr
+	}
+	return err
+}
+
+// AddSecondary adds a server which is started and shut down alongside
+// the main server, for example to redirect http to https.
+func (s *Server) AddSecondary(server *http.Server) {
+	s.secondary = append(s.secondary, server)
+}
+
+// run calls serve and waits for it to fail or for a signal to shut down.
+func (s *Server) run(serve func() error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), s.config.Signals...)
+	defer stop()
+
+	errs := make(chan error, 1+len(s.secondary))
+	go func() {
+		errs <- serve()
+	}()
+	for _, secondary := range s.secondary {
+		go func(secondary *http.Server) {
+			err := secondary.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}(secondary)
+	}
+
+	select {
+	case err := <-errs:
+		// A server failed to start or was shut down elsewhere
+		s.Shutdown()
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Infof("serve: shutting down, waiting up to %s for requests to finish", s.config.ShutdownTimeout)
+	err := s.Shutdown()
+	if err != nil {
+		return err
+	}
+
+	// Wait for serve to return after shutdown
+	err = <-errs
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package serve
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestServe tests Serve drains in-flight requests when a shutdown signal is received.
+func TestServe(t *testing.T) {
+	release := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("slow"))
+	})
+
+	l := httptest.NewUnstartedServer(nil).Listener
+	s := New(h, Config{ShutdownTimeout: 5 * time.Second, Signals: []os.Signal{syscall.SIGTERM}})
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	slow := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			slow <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		slow <- result{body: string(b), err: err}
+	}()
+	waitFor(t, func() bool { return s.Tracker.Stats().Requests == 1 })
+
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(syscall.SIGTERM)
+	}
+	if err != nil {
+		t.Skipf("serve: cannot send signal %s", err)
+	}
+	waitFor(t, s.Tracker.Draining)
+
+	// Serve waits for the request in flight
+	select {
+	case err := <-served:
+		t.Fatalf("serve: returned with a request in flight err:%v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if r := <-slow; r.err != nil || r.body != "slow" {
+		t.Errorf("serve: in-flight request failed got:%q err:%v", r.body, r.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve: error shutting down %s", err)
+	}
+	if stats := s.Tracker.Stats(); stats.Requests != 0 {
+		t.Errorf("serve: wrong stats after shutdown got:%+v", stats)
+	}
+}
+
+// TestH2C tests H2C servers accept HTTP/2 with prior knowledge as well as HTTP/1.
+func TestH2C(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%d", r.ProtoMajor)
+	})
+
+	l := httptest.NewUnstartedServer(nil).Listener
+	s := New(h, Config{H2C: true})
+	go s.Server.Serve(l)
+	defer s.Server.Close()
+
+	h2c := new(http.Protocols)
+	h2c.SetUnencryptedHTTP2(true)
+	h1 := new(http.Protocols)
+	h1.SetHTTP1(true)
+
+	tests := []struct {
+		protocols *http.Protocols
+		want      string
+	}{
+		{h2c, "2"},
+		{h1, "1"},
+	}
+	for _, tc := range tests {
+		transport := &http.Transport{Protocols: tc.protocols}
+		defer transport.CloseIdleConnections()
+		res, err := (&http.Client{Transport: transport}).Get("http://" + l.Addr().String())
+		if err != nil {
+			t.Fatalf("serve: error requesting %s", err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil || string(b) != tc.want || fmt.Sprint(res.ProtoMajor) != tc.want {
+			t.Errorf("serve: wrong protocol got:%s %s want:%s", b, res.Proto, tc.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// Stats represents the work in progress on a Tracker.
+type Stats struct {
+	Requests  int64 // Requests in flight, including long-lived requests
+	LongLived int64 // Long-lived connections such as SSE streams or websockets
+	Draining  bool  // True once draining has started
+}
+
+// Tracker counts in-flight requests and long-lived connections, and drains them
+// for zero-downtime deploys: once draining new requests are refused, and
+// long-lived handlers are notified through their context so they may finish.
+type Tracker struct {
+	// Progress is called with the stats every second while draining,
+	// if nil progress is logged.
+	Progress func(Stats)
+
+	requests  atomic.Int64
+	longLived atomic.Int64
+	draining  atomic.Bool
+
+	// ctx is cancelled when draining starts
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// drainPoll is the interval at which Drain checks for work in progress
+const drainPoll = 10 * time.Millisecond
+
+// trackerContextKey is the context key for the tracker serving a request
+type trackerContextKey struct{}
+
+// NewTracker returns a new tracker.
+func NewTracker() *Tracker {
+	t := &Tracker{}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return t
+}
+
+// Handler returns a handler which counts requests to h while they are in flight.
+// Once draining, requests are refused with 503 Service Unavailable.
+func (t *Tracker) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t.draining.Load() {
+			w.Header().Set("Connection", "close")
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		t.requests.Add(1)
+		defer t.requests.Add(-1)
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), trackerContextKey{}, t)))
+	})
+}
+
+// LongLived marks a long-lived connection (such as an SSE stream or a hijacked websocket),
+// which is counted until done is called. The context returned is cancelled when draining
+// starts or when parent is cancelled, handlers should then finish and call done.
+func (t *Tracker) LongLived(parent context.Context) (ctx context.Context, done func()) {
+	t.longLived.Add(1)
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(t.ctx, cancel)
+
+	var once atomic.Bool
+	return ctx, func() {
+		if once.Swap(true) {
+			return
+		}
+		stop()
+		cancel()
+		t.longLived.Add(-1)
+	}
+}
+
+// LongLived marks the request as long-lived on the tracker serving it, and returns
+// a context which is cancelled when draining starts or the request is cancelled.
+// It also clears the write deadline for the response, so that the server
+// WriteTimeout does not end the stream. Handlers which hijack the connection
+// should call it before hijacking, and manage deadlines on the connection.
+// If the request is not served by a tracker the request context is returned.
+//
+//	ctx, done := serve.LongLived(w, r)
+//	defer done()
+//	for {
+//		select {
+//		case <-ctx.Done():
+//			return
+//		case event := <-events:
+//			...
+//		}
+//	}
+func LongLived(w http.ResponseWriter, r *http.Request) (context.Context, func()) {
+	// Writers which do not support deadlines have no deadline to clear
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	t, ok := r.Context().Value(trackerContextKey{}).(*Tracker)
+	if !ok {
+		return r.Context(), func() {}
+	}
+	return t.LongLived(r.Context())
+}
+
+// Stats returns the work in progress.
+func (t *Tracker) Stats() Stats {
+	return Stats{
+		Requests:  t.requests.Load(),
+		LongLived: t.longLived.Load(),
+		Draining:  t.draining.Load(),
+	}
+}
+
+// Draining returns true once draining has started.
+func (t *Tracker) Draining() bool {
+	return t.draining.Load()
+}
+
+// Drain refuses new requests, notifies long-lived connections, and waits for
+// requests and long-lived connections to finish or for ctx to be cancelled.
+// Progress is reported every second while waiting.
+func (t *Tracker) Drain(ctx context.Context) error {
+	t.draining.Store(true)
+	t.cancel()
+
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+	reported := time.Now()
+	for {
+		stats := t.Stats()
+		if stats.Requests == 0 && stats.LongLived == 0 {
+			return nil
+		}
+		if time.Since(reported) >= time.Second {
+			reported = time.Now()
+			t.progress(stats)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// progress reports the stats while draining
+func (t *Tracker) progress(stats Stats) {
+	if t.Progress != nil {
+		t.Progress(stats)
+		return
+	}
+	log.Infof("serve: draining, %d requests and %d long-lived connections remaining", stats.Requests, stats.LongLived)
+}
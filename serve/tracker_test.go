@@ -0,0 +1,163 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestShutdown tests Shutdown waits for in-flight requests and long-lived connections.
+func TestShutdown(t *testing.T) {
+	release := make(chan struct{})
+	streamDone := make(chan struct{})
+	h := http.NewServeMux()
+	h.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("slow"))
+	})
+	h.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		defer close(streamDone)
+		ctx, done := LongLived(w, r)
+		defer done()
+		w.Write([]byte("data: hello\n\n"))
+		http.NewResponseController(w).Flush()
+		<-ctx.Done()
+	})
+
+	s := New(h, Config{ShutdownTimeout: 5 * time.Second})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("serve: error listening %s", err)
+	}
+	go s.Server.Serve(l)
+
+	url := "http://" + l.Addr().String()
+	slow := make(chan error, 1)
+	go func() {
+		res, err := http.Get(url + "/slow")
+		if err == nil {
+			res.Body.Close()
+		}
+		slow <- err
+	}()
+	res, err := http.Get(url + "/stream")
+	if err != nil {
+		t.Fatalf("serve: error opening stream %s", err)
+	}
+	defer res.Body.Close()
+
+	waitFor(t, func() bool {
+		stats := s.Tracker.Stats()
+		return stats.Requests == 2 && stats.LongLived == 1
+	})
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.Shutdown()
+	}()
+
+	// The stream is notified, but shutdown waits for the slow request
+	select {
+	case <-streamDone:
+	case <-time.After(time.Second):
+		t.Fatalf("serve: long-lived handler not notified of shutdown")
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("serve: shutdown returned with a request in flight err:%v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !s.Tracker.Draining() {
+		t.Errorf("serve: tracker not draining during shutdown")
+	}
+
+	close(release)
+	if err := <-shutdown; err != nil {
+		t.Errorf("serve: error shutting down %s", err)
+	}
+	if err := <-slow; err != nil {
+		t.Errorf("serve: in-flight request failed %s", err)
+	}
+	if stats := s.Tracker.Stats(); stats.Requests != 0 || stats.LongLived != 0 {
+		t.Errorf("serve: wrong stats after shutdown got:%+v", stats)
+	}
+}
+
+// TestDrainTimeout tests Drain returns when the context expires with work in progress.
+func TestDrainTimeout(t *testing.T) {
+	tracker := NewTracker()
+	_, done := tracker.LongLived(context.Background())
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := tracker.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("serve: wrong drain error got:%v want:%v", err, context.DeadlineExceeded)
+	}
+
+	// Requests are refused once draining
+	w := httptest.NewRecorder()
+	tracker.Handler(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("serve: wrong status while draining got:%d want:%d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	done()
+	done()
+	if stats := tracker.Stats(); stats.LongLived != 0 {
+		t.Errorf("serve: wrong long-lived count after done got:%d", stats.LongLived)
+	}
+}
+
+// TestLongLivedWriteTimeout tests long-lived responses are not ended by the WriteTimeout.
+func TestLongLivedWriteTimeout(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, done := LongLived(w, r)
+		defer done()
+		for i := 0; i < 3; i++ {
+			w.Write([]byte("data: tick\n\n"))
+			http.NewResponseController(w).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+	})
+
+	s := New(h, Config{WriteTimeout: 50 * time.Millisecond})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("serve: error listening %s", err)
+	}
+	go s.Server.Serve(l)
+	defer s.Server.Close()
+
+	res, err := http.Get("http://" + l.Addr().String())
+	if err != nil {
+		t.Fatalf("serve: error opening stream %s", err)
+	}
+	defer res.Body.Close()
+
+	ticks := 0
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		if scanner.Text() == "data: tick" {
+			ticks++
+		}
+	}
+	if ticks != 3 || scanner.Err() != nil {
+		t.Errorf("serve: stream ended early got:%d ticks err:%v", ticks, scanner.Err())
+	}
+}
+
+// waitFor waits up to a second for cond to be true
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("serve: timed out waiting")
+}
@@ -0,0 +1,55 @@
+package mux
+
+import (
+	"github.com/fragmenta/mux/middleware/gzip"
+	"github.com/fragmenta/mux/middleware/logrequest"
+	"github.com/fragmenta/mux/middleware/realip"
+	"github.com/fragmenta/mux/middleware/recovery"
+	"github.com/fragmenta/mux/middleware/requestid"
+	"github.com/fragmenta/mux/middleware/secure"
+)
+
+// Environments recognised by DefaultStack
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// DefaultStack returns the recommended middleware for the environment env,
+// in the order they should be added, outermost first:
+//
+//	for _, mw := range mux.DefaultStack(config.Env) {
+//	  m.AddMiddleware(mw)
+//	}
+//
+// Production uses recovery, requestid, realip, logrequest, secure and gzip.
+// Development writes panics to the response, logs without sending values,
+// and omits secure (whose HSTS header would pin localhost to https) and gzip.
+// Test uses only recovery and requestid, so that test output stays quiet.
+// Unknown environments use the production stack.
+// Middleware in the stack must not import this package.
+func DefaultStack(env string) []Middleware {
+	switch env {
+	case EnvDevelopment:
+		return []Middleware{
+			recovery.DebugMiddleware,
+			requestid.Middleware,
+			realip.Middleware,
+			logrequest.MiddlewarePrint,
+		}
+	case EnvTest:
+		return []Middleware{
+			recovery.Middleware,
+			requestid.Middleware,
+		}
+	}
+	return []Middleware{
+		recovery.Middleware,
+		requestid.Middleware,
+		realip.Middleware,
+		logrequest.Middleware,
+		secure.Middleware,
+		gzip.Middleware,
+	}
+}
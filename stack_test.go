@@ -0,0 +1,36 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDefaultStack tests the default middleware for each environment.
+func TestDefaultStack(t *testing.T) {
+	tests := map[string]int{
+		EnvDevelopment: 4,
+		EnvTest:        2,
+		EnvProduction:  6,
+		"":             6,
+	}
+	for env, want := range tests {
+		if got := len(DefaultStack(env)); got != want {
+			t.Errorf("stack: wrong middleware for %q got:%d want:%d", env, got, want)
+		}
+	}
+
+	m := New()
+	for _, mw := range DefaultStack(EnvTest) {
+		m.AddMiddleware(mw)
+	}
+	m.Get("/panic", func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError || w.Header().Get("X-Request-Id") == "" {
+		t.Errorf("stack: wrong response to panic got:%d %v", w.Code, w.Header())
+	}
+}
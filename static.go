@@ -0,0 +1,65 @@
+package mux
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// staticRoute is a route serving all paths below prefix
+type staticRoute struct {
+	prefix string
+	route  Route
+}
+
+// Static adds a route serving files from fsys for all paths below prefix,
+// for example m.Static("/assets/", os.DirFS("public/assets")).
+// Static routes are matched by prefix before other routes without
+// evaluating patterns. Files are served with Cache-Control: no-cache
+// and validators, use StaticServer to configure caching.
+func (m *Mux) Static(prefix string, fsys fs.FS) Route {
+	f := NewFileServerFS(fsys)
+	f.CacheControl = CacheNone
+	return m.StaticServer(prefix, f)
+}
+
+// StaticDir adds a route serving files from the directory dir for all paths below prefix.
+func (m *Mux) StaticDir(prefix, dir string) Route {
+	return m.Static(prefix, os.DirFS(dir))
+}
+
+// StaticServer adds a route serving files from the file server f for all paths below prefix,
+// paths are passed to f with the prefix removed.
+func (m *Mux) StaticServer(prefix string, f *FileServer) Route {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	route := m.Get(prefix+"{path:.*}", func(w http.ResponseWriter, r *http.Request) error {
+		return f.servePath(w, r, strings.TrimPrefix(r.URL.Path, prefix))
+	})
+	m.statics = append(m.statics, staticRoute{prefix: prefix, route: route})
+	return route
+}
+
+// File adds a route serving the single file at path for pattern,
+// for example m.File("/favicon.ico", "public/favicon.ico").
+func (m *Mux) File(pattern, path string) Route {
+	f := NewFileServer(filepath.Dir(path))
+	f.CacheControl = CacheNone
+	name := filepath.Base(path)
+	return m.Get(pattern, func(w http.ResponseWriter, r *http.Request) error {
+		return f.servePath(w, r, name)
+	})
+}
+
+// matchStatic returns the static route for this request, if any
+func (m *Mux) matchStatic(r *http.Request) Route {
+	for _, s := range m.statics {
+		if strings.HasPrefix(r.URL.Path, s.prefix) && s.route.MatchMethod(r.Method) {
+			return s.route
+		}
+	}
+	return nil
+}
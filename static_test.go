@@ -0,0 +1,60 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+// TestStatic tests serving files below a prefix, ahead of other routes.
+func TestStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.css":      &fstest.MapFile{Data: []byte("body{}")},
+		"img/logo.svg": &fstest.MapFile{Data: []byte("<svg></svg>")},
+	}
+
+	m := New()
+	m.Get("/{path:.*}", func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("catch all"))
+		return nil
+	})
+	m.Static("/assets", fsys)
+
+	tests := map[string]string{
+		"/assets/app.css":      "body{}",
+		"/assets/img/logo.svg": "<svg></svg>",
+		"/other":               "catch all",
+	}
+	for p, want := range tests {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		if w.Body.String() != want {
+			t.Errorf("static: wrong body for %s got:%s want:%s", p, w.Body.String(), want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/missing.css", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("static: wrong status for missing file got:%d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/app.css", nil))
+	if w.Header().Get("Cache-Control") != CacheNone || w.Header().Get("ETag") == "" {
+		t.Errorf("static: wrong cache headers got:%v", w.Header())
+	}
+}
+
+// TestFile tests serving a single file.
+func TestFile(t *testing.T) {
+	m := New()
+	m.File("/favicon.ico", "LICENSE")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("static: failed to serve file got:%d", w.Code)
+	}
+}
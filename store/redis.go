@@ -0,0 +1,74 @@
+package store
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// RedisStore stores values in redis, so that they are shared between servers.
+type RedisStore struct {
+	pool *redis.Pool
+}
+
+// NewRedisStore returns a new redis store using connections from pool.
+func NewRedisStore(pool *redis.Pool) *RedisStore {
+	return &RedisStore{pool: pool}
+}
+
+// Get returns the value for key, and false if it is not set or has expired.
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	value, err := redis.Bytes(conn.Do("GET", key))
+	if err == redis.ErrNil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set sets the value for key, expiring after ttl (or never if ttl is 0).
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+	var err error
+	if ttl > 0 {
+		_, err = conn.Do("SET", key, value, "PX", ttl.Milliseconds())
+	} else {
+		_, err = conn.Do("SET", key, value)
+	}
+	return err
+}
+
+// Incr increments the integer value for key and returns the new value.
+func (s *RedisStore) Incr(key string) (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	n, err := redis.Int64(conn.Do("INCR", key))
+	if err != nil {
+		if _, ok := err.(redis.Error); ok {
+			return 0, ErrNotInteger
+		}
+		return 0, err
+	}
+	return n, nil
+}
+
+// Expire sets the key to expire after ttl, if it is set.
+func (s *RedisStore) Expire(key string, ttl time.Duration) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("PEXPIRE", key, ttl.Milliseconds())
+	return err
+}
+
+// Delete removes the value for key.
+func (s *RedisStore) Delete(key string) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("DEL", key)
+	return err
+}
@@ -0,0 +1,173 @@
+// Package store defines a small key value store interface used by the cache
+// middleware for responses and the quota middleware for counters, with an
+// in-memory implementation and a redis implementation for state shared
+// between servers.
+package store
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Usage
+// s := store.NewRedisStore(pool)
+// q := quota.New(quota.Config{Store: s, ...})
+// c := cache.New(cache.Config{Store: s})
+
+// ErrNotInteger is returned by Incr if the value for a key is not an integer
+var ErrNotInteger = errors.New("store: value is not an integer")
+
+// Store stores values by key, implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value for key, and false if it is not set or has expired
+	Get(key string) ([]byte, bool, error)
+	// Set sets the value for key, expiring after ttl (or never if ttl is 0)
+	Set(key string, value []byte, ttl time.Duration) error
+	// Incr increments the integer value for key, setting it to 1 if it is not set,
+	// and returns the new value. The expiry of the key is not changed.
+	Incr(key string) (int64, error)
+	// Expire sets the key to expire after ttl
+	Expire(key string, ttl time.Duration) error
+	// Delete removes the value for key
+	Delete(key string) error
+}
+
+// MemoryStore stores values in memory, so values are not shared between processes.
+type MemoryStore struct {
+	max int
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	swept   time.Time
+}
+
+// memoryEntry is a value and its expiry time
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore returns a new memory store holding up to max entries (or
+// any number if max is 0). Expired entries are removed periodically, and when
+// the store is full, then arbitrary entries are removed if required.
+func NewMemoryStore(max int) *MemoryStore {
+	return &MemoryStore{
+		max:     max,
+		entries: make(map[string]memoryEntry),
+		swept:   time.Now(),
+	}
+}
+
+// Get returns the value for key, and false if it is not set or has expired.
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.get(key, time.Now())
+	if !ok {
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set sets the value for key, expiring after ttl (or never if ttl is 0).
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	e := memoryEntry{value: value}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	s.set(key, e, now)
+	return nil
+}
+
+// Incr increments the integer value for key and returns the new value.
+func (s *MemoryStore) Incr(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	e, ok := s.get(key, now)
+	n := int64(0)
+	if ok {
+		var err error
+		n, err = strconv.ParseInt(string(e.value), 10, 64)
+		if err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+	n++
+	e.value = []byte(strconv.FormatInt(n, 10))
+	s.set(key, e, now)
+	return n, nil
+}
+
+// Expire sets the key to expire after ttl, if it is set.
+func (s *MemoryStore) Expire(key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if e, ok := s.get(key, now); ok {
+		e.expires = now.Add(ttl)
+		s.entries[key] = e
+	}
+	return nil
+}
+
+// Delete removes the value for key.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// get returns the entry for key if it has not expired, removing it if it has
+func (s *MemoryStore) get(key string, now time.Time) (memoryEntry, bool) {
+	e, ok := s.entries[key]
+	if ok && e.expired(now) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return e, ok
+}
+
+// set sets the entry for key, removing entries first if required
+func (s *MemoryStore) set(key string, e memoryEntry, now time.Time) {
+	if now.Sub(s.swept) > time.Minute {
+		s.sweep(now)
+	}
+	if _, ok := s.entries[key]; !ok && s.max > 0 && len(s.entries) >= s.max {
+		s.evict(now)
+	}
+	s.entries[key] = e
+}
+
+// sweep removes expired entries
+func (s *MemoryStore) sweep(now time.Time) {
+	for k, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, k)
+		}
+	}
+	s.swept = now
+}
+
+// evict removes expired entries, and if none have expired, removes
+// arbitrary entries to make room for one more
+func (s *MemoryStore) evict(now time.Time) {
+	s.sweep(now)
+	for k := range s.entries {
+		if len(s.entries) < s.max {
+			break
+		}
+		delete(s.entries, k)
+	}
+}
+
+// expired returns true if the entry has expired at now
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestMemoryStore tests values are set, replaced and deleted.
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore(0)
+
+	if _, ok, _ := s.Get("a"); ok {
+		t.Errorf("store: unexpected value for unset key")
+	}
+	s.Set("a", []byte("1"), 0)
+	s.Set("a", []byte("2"), 0)
+	if v, ok, err := s.Get("a"); !ok || err != nil || string(v) != "2" {
+		t.Errorf("store: wrong value got:%q %t %v", v, ok, err)
+	}
+	s.Delete("a")
+	if _, ok, _ := s.Get("a"); ok {
+		t.Errorf("store: value not deleted")
+	}
+}
+
+// TestMemoryStoreExpiry tests values expire after their ttl.
+func TestMemoryStoreExpiry(t *testing.T) {
+	s := NewMemoryStore(0)
+	s.Set("short", []byte("1"), 10*time.Millisecond)
+	s.Set("long", []byte("1"), time.Hour)
+	s.Set("forever", []byte("1"), 0)
+	s.Set("expire", []byte("1"), 0)
+	s.Expire("expire", 10*time.Millisecond)
+	s.Expire("unset", time.Hour)
+
+	time.Sleep(20 * time.Millisecond)
+
+	for key, want := range map[string]bool{"short": false, "long": true, "forever": true, "expire": false, "unset": false} {
+		if _, ok, _ := s.Get(key); ok != want {
+			t.Errorf("store: wrong expiry for %s got:%t want:%t", key, ok, want)
+		}
+	}
+}
+
+// TestMemoryStoreEviction tests the store holds at most max entries, removing expired entries first.
+func TestMemoryStoreEviction(t *testing.T) {
+	s := NewMemoryStore(3)
+	s.Set("expired", []byte("1"), time.Millisecond)
+	s.Set("a", []byte("1"), 0)
+	s.Set("b", []byte("1"), 0)
+	time.Sleep(5 * time.Millisecond)
+
+	// The expired entry should be removed to make room
+	s.Set("c", []byte("1"), 0)
+	for _, key := range []string{"a", "b", "c"} {
+		if _, ok, _ := s.Get(key); !ok {
+			t.Errorf("store: %s evicted before expired entry", key)
+		}
+	}
+
+	// Replacing a key does not evict
+	s.Set("c", []byte("2"), 0)
+	if len(s.entries) != 3 {
+		t.Errorf("store: wrong entries after replace got:%d want:3", len(s.entries))
+	}
+
+	for i := 0; i < 10; i++ {
+		s.Set(strconv.Itoa(i), []byte("1"), 0)
+	}
+	if len(s.entries) != 3 {
+		t.Errorf("store: wrong entries when full got:%d want:3", len(s.entries))
+	}
+	if _, ok, _ := s.Get("9"); !ok {
+		t.Errorf("store: newest entry evicted")
+	}
+}
+
+// TestMemoryStoreIncr tests counters are incremented and keep their expiry.
+func TestMemoryStoreIncr(t *testing.T) {
+	s := NewMemoryStore(0)
+	for i := int64(1); i <= 3; i++ {
+		n, err := s.Incr("count")
+		if err != nil || n != i {
+			t.Errorf("store: wrong count got:%d %v want:%d", n, err, i)
+		}
+	}
+
+	s.Expire("count", 10*time.Millisecond)
+	s.Incr("count")
+	time.Sleep(20 * time.Millisecond)
+	if n, _ := s.Incr("count"); n != 1 {
+		t.Errorf("store: count did not expire got:%d want:1", n)
+	}
+
+	s.Set("name", []byte("alice"), 0)
+	if _, err := s.Incr("name"); err != ErrNotInteger {
+		t.Errorf("store: wrong error for non integer got:%v want:%v", err, ErrNotInteger)
+	}
+}
@@ -0,0 +1,83 @@
+package mux
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// Timing records the time spent in each stage of serving a request by the mux.
+type Timing struct {
+	// Match is the time spent matching the route
+	Match time.Duration
+	// Middleware is the time spent in middleware, and waiting for concurrency limits
+	Middleware time.Duration
+	// Handler is the time spent in the route handler
+	Handler time.Duration
+	// Error is the time spent in the ErrorHandler
+	Error time.Duration
+	// Total is the time spent in the mux, excluding request and response hooks
+	Total time.Duration
+
+	// route is the time spent in RouteRequest
+	route time.Duration
+}
+
+// TimingHook is called after each request is served with the timing for the request
+type TimingHook func(r *http.Request, t Timing)
+
+// keyTiming is the request value key for the *Timing of a request
+const keyTiming ValueKey = "mux_timing"
+
+// OnTiming adds a hook called after every request with a breakdown of the time
+// spent matching, in middleware, in the handler and in the ErrorHandler, to
+// diagnose slow requests. Timing is only recorded if hooks are set.
+// Hooks should be added before serving.
+func (m *Mux) OnTiming(hook TimingHook) {
+	m.timingHooks = append(m.timingHooks, hook)
+}
+
+// TimingValues returns a TimingHook which sends timings in nanoseconds
+// to log.Values under the series name given, with the route pattern.
+func TimingValues(series string) TimingHook {
+	return func(r *http.Request, t Timing) {
+		values := map[string]interface{}{
+			log.SeriesName: series,
+			"method":       r.Method,
+			"match":        t.Match.Nanoseconds(),
+			"middleware":   t.Middleware.Nanoseconds(),
+			"handler":      t.Handler.Nanoseconds(),
+			"error":        t.Error.Nanoseconds(),
+			"total":        t.Total.Nanoseconds(),
+		}
+		if route := RouteFromContext(r); route != nil {
+			values["route"] = route.Pattern()
+		}
+		log.Values(values)
+	}
+}
+
+// startTiming stores a new Timing on the request, and returns a
+// function which completes it and calls the timing hooks.
+func (m *Mux) startTiming(r *http.Request) func() {
+	t := &Timing{}
+	SetValue(r, keyTiming, t)
+	start := time.Now()
+	return func() {
+		t.Total = time.Since(start)
+		t.Middleware = t.Total - t.route
+		for _, hook := range m.timingHooks {
+			hook(r, *t)
+		}
+	}
+}
+
+// timing returns the Timing for the request, or nil if timing is off
+func (m *Mux) timing(r *http.Request) *Timing {
+	if len(m.timingHooks) == 0 {
+		return nil
+	}
+	t, _ := Value[*Timing](r, keyTiming)
+	return t
+}
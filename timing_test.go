@@ -0,0 +1,57 @@
+package mux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// TestTiming tests timing hooks report the time spent in each stage.
+func TestTiming(t *testing.T) {
+	rec := &valuesRecorder{}
+	log.AddValuesLogger(rec, log.SeriesFilter("mux_timing"))
+	defer log.RemoveValuesLogger(rec)
+
+	m := New()
+	m.AddMiddleware(func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(2 * time.Millisecond)
+			h(w, r)
+		}
+	})
+	m.Get("/slow", func(w http.ResponseWriter, r *http.Request) error {
+		time.Sleep(5 * time.Millisecond)
+		return errors.New("failed")
+	})
+	m.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		time.Sleep(time.Millisecond)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	var timings []Timing
+	m.OnTiming(func(r *http.Request, tm Timing) {
+		timings = append(timings, tm)
+	})
+	m.OnTiming(TimingValues("mux_timing"))
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if len(timings) != 1 {
+		t.Fatalf("timing: expected 1 timing got:%d", len(timings))
+	}
+	tm := timings[0]
+	if tm.Middleware < 2*time.Millisecond || tm.Handler < 5*time.Millisecond || tm.Error < time.Millisecond {
+		t.Errorf("timing: wrong durations got:%+v", tm)
+	}
+	if tm.Total < tm.Match+tm.Middleware+tm.Handler+tm.Error {
+		t.Errorf("timing: total less than stages got:%+v", tm)
+	}
+
+	if len(rec.values) != 1 || rec.values[0]["route"] != "/slow" || rec.values[0]["handler"].(int64) < int64(5*time.Millisecond) {
+		t.Errorf("timing: wrong values got:%v", rec.values)
+	}
+}
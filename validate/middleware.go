@@ -0,0 +1,204 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fragmenta/mux"
+)
+
+// Usage
+// validate.Request(m.Post("/users", users.HandleCreate), users.CreateUser{})
+// m.AddMiddleware(validate.Middleware(m))
+// ...
+// user, ok := validate.Value[users.CreateUser](r)
+
+// MetaKey is the route metadata key under which Request stores the type to decode
+const MetaKey = "validate"
+
+// Key is the request value key for the decoded value
+const Key mux.ValueKey = "validated"
+
+// ErrorHandler writes the response for invalid requests, by default
+// a 400 Bad Request with a json body of the form {"errors":{"field":"message"}}.
+var ErrorHandler = func(w http.ResponseWriter, r *http.Request, errs Errors) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": errs})
+}
+
+// Request declares that requests for route are decoded into a new value of the
+// type of v (a struct) and validated by Middleware before the handler runs.
+// It returns the route for chaining, and panics if the rules for v are invalid.
+func Request(route mux.Route, v interface{}) mux.Route {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic("validate: request type must be a struct")
+	}
+	fields(t)
+	return route.Meta(MetaKey, t)
+}
+
+// Middleware returns middleware which decodes the params for routes declared
+// with Request into a new value, from path, query and form params and json bodies,
+// and validates it. Invalid requests are passed to ErrorHandler without calling
+// the handler, valid values are stored on the request for Value.
+// The json body is restored after reading so that handlers may read it again.
+func Middleware(m *mux.Mux) mux.Middleware {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			route := m.RouteFor(r)
+			t, ok := mux.RouteMeta[reflect.Type](route, MetaKey)
+			if !ok {
+				h(w, r)
+				return
+			}
+
+			v, err := decode(m, r, route, t)
+			if err == nil {
+				err = Struct(v)
+			}
+			var errs Errors
+			if errors.As(err, &errs) {
+				mux.SetValue(r, mux.KeyError, mux.NewStatusError(http.StatusBadRequest, err))
+				ErrorHandler(w, r, errs)
+				return
+			} else if err != nil {
+				mux.SetValue(r, mux.KeyError, err)
+				m.ErrorHandler(w, r, err)
+				return
+			}
+
+			mux.SetValue(r, Key, v)
+			h(w, r)
+		}
+	}
+}
+
+// Value returns the value decoded and validated for the request by Middleware.
+func Value[T any](r *http.Request) (*T, bool) {
+	return mux.Value[*T](r, Key)
+}
+
+// decode returns a pointer to a new value of type t, set from the request params
+func decode(m *mux.Mux, r *http.Request, route mux.Route, t reflect.Type) (interface{}, error) {
+	ptr := reflect.New(t)
+
+	params, err := mux.ParamsWithMux(m, r)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := Errors{}
+	for _, f := range fields(t) {
+		values, ok := params.Values[f.name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if msg := setField(ptr.Elem().Field(f.index), values); msg != "" {
+			errs[f.name] = msg
+		}
+	}
+
+	if r.Body != nil && !route.IsRawBody() && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return nil, mux.NewStatusError(http.StatusRequestEntityTooLarge, err)
+			}
+			if cerr := mux.Cancelled(r); cerr != nil {
+				return nil, cerr
+			}
+			return nil, mux.NewStatusError(http.StatusBadRequest, err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if len(body) > 0 {
+			err = json.Unmarshal(body, ptr.Interface())
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field != "" {
+				errs[typeErr.Field] = "must be a " + typeName(typeErr.Type)
+			} else if err != nil {
+				errs["body"] = "must be valid json"
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return ptr.Interface(), nil
+}
+
+// setField sets the field from string params, returning a description of any error
+func setField(v reflect.Value, values []string) string {
+	if v.Kind() == reflect.Slice {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if msg := setValue(s.Index(i), value); msg != "" {
+				return msg
+			}
+		}
+		v.Set(s)
+		return ""
+	}
+	return setValue(v, values[0])
+}
+
+// setValue sets v from the string s, returning a description of any error
+func setValue(v reflect.Value, s string) string {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return "must be true or false"
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return "must be a positive integer"
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return "must be a number"
+		}
+		v.SetFloat(f)
+	}
+	return ""
+}
+
+// typeName returns a description of t for errors
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	}
+	return "number"
+}
@@ -0,0 +1,115 @@
+package validate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fragmenta/mux"
+)
+
+// user is decoded by the middleware in tests
+type user struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name" validate:"required,max=10"`
+	Admin bool   `json:"admin"`
+}
+
+// serve serves a request to a mux validating user params, and returns the
+// response and the user stored for the handler, or nil if it was not called.
+func serve(method, path, contentType, body string) (*httptest.ResponseRecorder, *user) {
+	m := mux.New()
+	m.AddMiddleware(Middleware(m))
+
+	var got *user
+	handler := func(w http.ResponseWriter, r *http.Request) error {
+		got, _ = Value[user](r)
+		// The body must be available to the handler again
+		b, _ := io.ReadAll(r.Body)
+		w.Write(b)
+		return nil
+	}
+	Request(m.Add("/users/{id:int}", handler).Methods(http.MethodPost), user{})
+
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	return w, got
+}
+
+// TestMiddleware tests valid requests are decoded from params and json bodies.
+func TestMiddleware(t *testing.T) {
+	w, u := serve(http.MethodPost, "/users/3?admin=true", "application/x-www-form-urlencoded", "name=alice")
+	if u == nil || u.ID != 3 || u.Name != "alice" || !u.Admin {
+		t.Errorf("validate: wrong form value got:%+v", u)
+	}
+
+	w, u = serve(http.MethodPost, "/users/4", "application/json", `{"name":"bob"}`)
+	if u == nil || u.ID != 4 || u.Name != "bob" {
+		t.Errorf("validate: wrong json value got:%+v", u)
+	}
+	if w.Body.String() != `{"name":"bob"}` {
+		t.Errorf("validate: body not restored got:%q", w.Body.String())
+	}
+}
+
+// TestMiddlewareErrors tests invalid requests receive field errors without calling the handler.
+func TestMiddlewareErrors(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+		want        Errors
+	}{
+		{"/users/1", "application/x-www-form-urlencoded", "", Errors{"name": "is required"}},
+		{"/users/1", "application/x-www-form-urlencoded", "name=alice&admin=maybe", Errors{"admin": "must be true or false"}},
+		{"/users/1", "application/json", `{"name":"alice alice alice"}`, Errors{"name": "must be at most 10 characters"}},
+		{"/users/1", "application/json", `{"name":12}`, Errors{"name": "must be a string"}},
+		{"/users/1", "application/json", `{"name":"alice","admin":"yes"}`, Errors{"admin": "must be a boolean"}},
+		{"/users/1", "application/json", `{"name":"alice","id":"one"}`, Errors{"id": "must be a number"}},
+		{"/users/1", "application/json", `{"name":`, Errors{"body": "must be valid json"}},
+	}
+
+	for _, tc := range tests {
+		w, u := serve(http.MethodPost, tc.path, tc.contentType, tc.body)
+		if u != nil {
+			t.Errorf("validate: handler called for %s", tc.body)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("validate: wrong status for %s got:%d want:%d", tc.body, w.Code, http.StatusBadRequest)
+		}
+		var res struct{ Errors Errors }
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil || len(res.Errors) != len(tc.want) {
+			t.Errorf("validate: wrong errors for %s got:%v want:%v", tc.body, res.Errors, tc.want)
+			continue
+		}
+		for k, v := range tc.want {
+			if res.Errors[k] != v {
+				t.Errorf("validate: wrong error for %s got:%v want:%v", tc.body, res.Errors, tc.want)
+			}
+		}
+	}
+}
+
+// TestMiddlewareUndeclared tests routes without a request type are not validated.
+func TestMiddlewareUndeclared(t *testing.T) {
+	m := mux.New()
+	m.AddMiddleware(Middleware(m))
+	called := false
+	m.Post("/", func(w http.ResponseWriter, r *http.Request) error {
+		called = true
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{")))
+	if !called || w.Code != http.StatusOK {
+		t.Errorf("validate: undeclared route not served got:%d", w.Code)
+	}
+}
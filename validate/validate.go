@@ -0,0 +1,237 @@
+// Package validate checks values against rules declared in struct tags,
+// and provides middleware which decodes and validates the params for routes
+// before their handlers run, rejecting invalid requests with field errors.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Rules are declared in the validate tag of struct fields, separated by commas:
+//
+//	type CreateUser struct {
+//		Name  string `json:"name" validate:"required,max=50"`
+//		Email string `json:"email" validate:"required,email"`
+//		Role  string `json:"role" validate:"oneof=admin editor reader"`
+//		Age   int    `json:"age" validate:"min=13"`
+//		Code  string `json:"code" validate:"pattern=^[A-Z]{3}$"`
+//	}
+//
+// required rejects zero values, min and max limit the length of strings and
+// slices or the value of numbers, email requires an email address, oneof
+// requires one of the space separated values, and pattern requires a match
+// for the regexp, which must be the last rule as it may contain commas.
+// Fields are named in errors by their json tag, or their name if none.
+
+// Errors maps field names to a description of their error.
+type Errors map[string]string
+
+// Error returns the field errors sorted by field name
+func (e Errors) Error() string {
+	var fields []string
+	for f := range e {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	var msgs []string
+	for _, f := range fields {
+		msgs = append(msgs, f+" "+e[f])
+	}
+	return "validate: " + strings.Join(msgs, ", ")
+}
+
+// Struct validates the fields of the struct v (or a pointer to one)
+// against the rules in their tags, and returns nil if all are valid.
+func Struct(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: %T is not a struct", v)
+	}
+
+	errs := Errors{}
+	for _, f := range fields(rv.Type()) {
+		if msg := f.check(rv.Field(f.index)); msg != "" {
+			errs[f.name] = msg
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// field is a struct field with its rules
+type field struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// rule is a single rule parsed from a tag
+type rule struct {
+	name    string
+	arg     string
+	number  float64
+	pattern *regexp.Regexp
+}
+
+// emailPattern is a permissive check for an email address
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// fieldCache stores parsed fields by struct type
+var fieldCache sync.Map
+
+// fields returns the fields of struct type t, parsing their tags once
+func fields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var parsed []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		f := field{index: i, name: fieldName(sf)}
+		if f.name == "" {
+			continue
+		}
+		f.rules = parseRules(sf.Tag.Get("validate"))
+		parsed = append(parsed, f)
+	}
+
+	fieldCache.Store(t, parsed)
+	return parsed
+}
+
+// fieldName returns the name of the field from its json tag, or "" if it is skipped
+func fieldName(sf reflect.StructField) string {
+	name := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	return name
+}
+
+// parseRules parses the rules in a validate tag, invalid rules panic
+// as they are programming errors found when a type is first validated.
+func parseRules(tag string) []rule {
+	var rules []rule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "pattern=") {
+			part, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+
+		r := rule{name: part}
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			r.name, r.arg = part[:i], part[i+1:]
+		}
+		switch r.name {
+		case "required", "email", "oneof":
+		case "min", "max":
+			n, err := strconv.ParseFloat(r.arg, 64)
+			if err != nil {
+				panic("validate: invalid number in rule " + part)
+			}
+			r.number = n
+		case "pattern":
+			r.pattern = regexp.MustCompile(r.arg)
+		default:
+			panic("validate: unknown rule " + part)
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// check returns a description of the first rule v fails, or "" if it passes all rules.
+// Rules other than required are not checked for zero values.
+func (f field) check(v reflect.Value) string {
+	for _, r := range f.rules {
+		if r.name == "required" {
+			if v.IsZero() {
+				return "is required"
+			}
+			continue
+		}
+		if v.IsZero() {
+			continue
+		}
+
+		switch r.name {
+		case "min":
+			if n, ok := size(v); ok && n < r.number {
+				return "must be at least " + r.arg + unit(v)
+			}
+		case "max":
+			if n, ok := size(v); ok && n > r.number {
+				return "must be at most " + r.arg + unit(v)
+			}
+		case "email":
+			if !emailPattern.MatchString(fmt.Sprint(v.Interface())) {
+				return "must be an email address"
+			}
+		case "oneof":
+			if !oneOf(fmt.Sprint(v.Interface()), strings.Fields(r.arg)) {
+				return "must be one of " + strings.Join(strings.Fields(r.arg), ", ")
+			}
+		case "pattern":
+			if !r.pattern.MatchString(fmt.Sprint(v.Interface())) {
+				return "is invalid"
+			}
+		}
+	}
+	return ""
+}
+
+// size returns the length of strings and slices or the value of numbers
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// unit returns the unit for min and max errors on v
+func unit(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return " items"
+	}
+	return ""
+}
+
+// oneOf returns true if s is in values
+func oneOf(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
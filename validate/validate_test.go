@@ -0,0 +1,98 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+)
+
+// rules is a struct with each of the rules
+type rules struct {
+	Name   string   `json:"name" validate:"required,max=5"`
+	Email  string   `json:"email" validate:"email"`
+	Role   string   `json:"role" validate:"oneof=admin editor"`
+	Age    int      `json:"age" validate:"min=13,max=120"`
+	Score  float64  `json:"score" validate:"max=1.5"`
+	Tags   []string `json:"tags" validate:"min=2"`
+	Code   string   `validate:"pattern=^[A-Z]{3}(,[A-Z]{3})?$"`
+	Secret string   `json:"-" validate:"required"`
+	hidden string
+}
+
+// TestStruct tests each rule.
+func TestStruct(t *testing.T) {
+	valid := rules{Name: "alice", Email: "a@example.com", Role: "admin", Age: 30, Score: 1, Tags: []string{"a", "b"}, Code: "ABC,DEF"}
+
+	tests := []struct {
+		name   string
+		modify func(*rules)
+		field  string
+		want   string
+	}{
+		{"valid", func(r *rules) {}, "", ""},
+		{"required", func(r *rules) { r.Name = "" }, "name", "is required"},
+		{"max string", func(r *rules) { r.Name = "alice2" }, "name", "must be at most 5 characters"},
+		{"email", func(r *rules) { r.Email = "alice" }, "email", "must be an email address"},
+		{"oneof", func(r *rules) { r.Role = "reader" }, "role", "must be one of admin, editor"},
+		{"min int", func(r *rules) { r.Age = 12 }, "age", "must be at least 13"},
+		{"max int", func(r *rules) { r.Age = 121 }, "age", "must be at most 120"},
+		{"max float", func(r *rules) { r.Score = 1.6 }, "score", "must be at most 1.5"},
+		{"min slice", func(r *rules) { r.Tags = []string{"a"} }, "tags", "must be at least 2 items"},
+		{"pattern", func(r *rules) { r.Code = "abc" }, "Code", "is invalid"},
+	}
+
+	for _, tc := range tests {
+		r := valid
+		tc.modify(&r)
+		err := Struct(&r)
+		if tc.field == "" {
+			if err != nil {
+				t.Errorf("validate: %s unexpected error %s", tc.name, err)
+			}
+			continue
+		}
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[tc.field] != tc.want {
+			t.Errorf("validate: %s wrong errors got:%v want:%s %s", tc.name, err, tc.field, tc.want)
+		}
+	}
+}
+
+// TestStructZero tests rules other than required are skipped for zero values.
+func TestStructZero(t *testing.T) {
+	err := Struct(rules{Name: "bob"})
+	if err != nil {
+		t.Errorf("validate: unexpected error for zero values %s", err)
+	}
+
+	err = Struct(struct {
+		Age int `validate:"required,min=1"`
+	}{})
+	if err == nil || err.Error() != "validate: Age is required" {
+		t.Errorf("validate: wrong error for required zero value got:%v", err)
+	}
+}
+
+// TestStructInvalid tests invalid values and rules.
+func TestStructInvalid(t *testing.T) {
+	if err := Struct("alice"); err == nil {
+		t.Errorf("validate: no error for string")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("validate: no panic for unknown rule")
+		}
+	}()
+	Struct(struct {
+		Name string `validate:"unknown"`
+	}{})
+}
+
+// TestErrors tests errors are listed by field name.
+func TestErrors(t *testing.T) {
+	errs := Errors{"name": "is required", "age": "must be at least 13"}
+	want := "validate: age must be at least 13, name is required"
+	if errs.Error() != want {
+		t.Errorf("validate: wrong error got:%q want:%q", errs.Error(), want)
+	}
+}
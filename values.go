@@ -0,0 +1,108 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/fragmenta/mux/log"
+)
+
+// ValueKey is the key for request scoped values set with SetValue,
+// middleware and handlers can share values using the well-known keys below
+// or their own keys without defining context key types.
+type ValueKey string
+
+// Well-known keys for request scoped values
+const (
+	// KeyRoute is the Route matched for the request, set by the mux
+	KeyRoute ValueKey = "route"
+	// KeyParams is the *RequestParams last parsed by Params for the request
+	KeyParams ValueKey = "params"
+	// KeyRequestID is the request id, also read from the request log fields
+	KeyRequestID ValueKey = log.FieldRequestID
+	// KeyUser is the authenticated user, set by authentication middleware
+	KeyUser ValueKey = "user"
+	// KeyTenant is the tenant for the request, set by the tenant middleware
+	KeyTenant ValueKey = "tenant"
+	// KeyLocale is the locale for the request, set by the i18n middleware
+	KeyLocale ValueKey = "locale"
+	// KeyError is the error passed to the ErrorHandler, also set in the request log fields
+	KeyError ValueKey = log.FieldError
+)
+
+// values stores request scoped values, and is safe for concurrent use.
+type values struct {
+	mu     sync.RWMutex
+	values map[ValueKey]interface{}
+}
+
+// valuesContextKey is the context key for request values
+type valuesContextKey struct{}
+
+// WithValues returns a request with a store for values in its context,
+// if the request already has one it is returned unchanged.
+// The mux calls this in ServeHTTP before any middleware.
+func WithValues(r *http.Request) *http.Request {
+	if valuesFromRequest(r) != nil {
+		return r
+	}
+	v := &values{values: make(map[ValueKey]interface{})}
+	return r.WithContext(context.WithValue(r.Context(), valuesContextKey{}, v))
+}
+
+// copyValues returns a request with a copy of the request values (or an empty
+// store if it has none), so that values set on the returned request do not affect r.
+func copyValues(r *http.Request) *http.Request {
+	v := &values{values: make(map[ValueKey]interface{})}
+	if existing := valuesFromRequest(r); existing != nil {
+		existing.mu.RLock()
+		for k, value := range existing.values {
+			v.values[k] = value
+		}
+		existing.mu.RUnlock()
+	}
+	return r.WithContext(context.WithValue(r.Context(), valuesContextKey{}, v))
+}
+
+// SetValue sets the value for key on the request if it has a store
+// (requests served by a mux always do), and does nothing otherwise.
+func SetValue(r *http.Request, key ValueKey, value interface{}) {
+	v := valuesFromRequest(r)
+	if v == nil {
+		return
+	}
+	v.mu.Lock()
+	v.values[key] = value
+	v.mu.Unlock()
+}
+
+// Value returns the value for key on the request if it is set and of type T.
+// If the key has not been set, the request log fields are checked,
+// so values recorded by middleware such as requestid are also available.
+func Value[T any](r *http.Request, key ValueKey) (T, bool) {
+	var zero T
+	if r == nil {
+		return zero, false
+	}
+
+	var value interface{}
+	ok := false
+	if v := valuesFromRequest(r); v != nil {
+		v.mu.RLock()
+		value, ok = v.values[key]
+		v.mu.RUnlock()
+	}
+	if !ok {
+		value = log.GetField(r, string(key))
+	}
+
+	t, ok := value.(T)
+	return t, ok
+}
+
+// valuesFromRequest returns the values stored in the request context, or nil if none.
+func valuesFromRequest(r *http.Request) *values {
+	v, _ := r.Context().Value(valuesContextKey{}).(*values)
+	return v
+}
@@ -0,0 +1,65 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fragmenta/mux/log"
+)
+
+type testUser struct {
+	ID int
+}
+
+// TestValues tests request values are shared between middleware and handlers.
+func TestValues(t *testing.T) {
+	m := New()
+	m.AddMiddleware(func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			SetValue(r, KeyUser, &testUser{ID: 3})
+			h(w, r)
+		}
+	})
+	m.Get(`/users/{id:\d+}`, func(w http.ResponseWriter, r *http.Request) error {
+		user, ok := Value[*testUser](r, KeyUser)
+		if !ok || user.ID != 3 {
+			t.Errorf("values: user not set got:%v", user)
+		}
+		route, ok := Value[Route](r, KeyRoute)
+		if !ok || route.Pattern() != `/users/{id:\d+}` {
+			t.Errorf("values: route not set got:%v", route)
+		}
+		if _, ok := Value[string](r, KeyUser); ok {
+			t.Errorf("values: value returned for wrong type")
+		}
+		if id, ok := Value[string](r, KeyRequestID); !ok || id != "abc" {
+			t.Errorf("values: request id not read from log fields got:%s", id)
+		}
+
+		params, err := Params(r)
+		if err != nil {
+			return err
+		}
+		stored, ok := Value[*RequestParams](r, KeyParams)
+		if !ok || stored != params {
+			t.Errorf("values: params not stored")
+		}
+		return nil
+	})
+
+	r := log.WithFields(httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	log.SetField(r, log.FieldRequestID, "abc")
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("values: wrong status got:%d", w.Code)
+	}
+
+	// Values without a store are ignored
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	SetValue(r, KeyUser, "bob")
+	if _, ok := Value[string](r, KeyUser); ok {
+		t.Errorf("values: value set without store")
+	}
+}
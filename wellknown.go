@@ -0,0 +1,195 @@
+package mux
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RobotsRule is a group of rules in robots.txt for a user agent
+type RobotsRule struct {
+	UserAgent  string // defaults to *
+	Allow      []string
+	Disallow   []string
+	CrawlDelay int // in seconds, 0 for none
+}
+
+// RobotsPolicy describes the contents of robots.txt
+type RobotsPolicy struct {
+	Rules    []RobotsRule
+	Sitemaps []string // absolute urls of sitemaps
+}
+
+// String returns the policy formatted for robots.txt
+func (p RobotsPolicy) String() string {
+	var b strings.Builder
+	for i, rule := range p.Rules {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		agent := rule.UserAgent
+		if agent == "" {
+			agent = "*"
+		}
+		fmt.Fprintf(&b, "User-agent: %s\n", agent)
+		for _, a := range rule.Allow {
+			fmt.Fprintf(&b, "Allow: %s\n", a)
+		}
+		for _, d := range rule.Disallow {
+			fmt.Fprintf(&b, "Disallow: %s\n", d)
+		}
+		// An empty disallow allows everything
+		if len(rule.Allow) == 0 && len(rule.Disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+		if rule.CrawlDelay > 0 {
+			fmt.Fprintf(&b, "Crawl-delay: %d\n", rule.CrawlDelay)
+		}
+	}
+	if len(p.Sitemaps) > 0 {
+		if len(p.Rules) > 0 {
+			b.WriteString("\n")
+		}
+		for _, s := range p.Sitemaps {
+			fmt.Fprintf(&b, "Sitemap: %s\n", s)
+		}
+	}
+	return b.String()
+}
+
+// Robots adds a route serving /robots.txt with the policy given.
+func (m *Mux) Robots(policy RobotsPolicy) Route {
+	body := policy.String()
+	return m.Get("/robots.txt", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err := w.Write([]byte(body))
+		return err
+	})
+}
+
+// SitemapURL is a url listed in a sitemap
+type SitemapURL struct {
+	Loc        string    // absolute url of the page
+	LastMod    time.Time // time the page was last modified, zero if unknown
+	ChangeFreq string    // always, hourly, daily, weekly, monthly, yearly or never
+	Priority   float64   // priority from 0.0 to 1.0, 0 to omit
+}
+
+// SitemapGenerator returns the urls for a sitemap, it is called for each request
+// so it should cache results if generating them is expensive.
+type SitemapGenerator func(r *http.Request) ([]SitemapURL, error)
+
+// sitemapURLSet is the xml document for a sitemap
+type sitemapURLSet struct {
+	XMLName xml.Name         `xml:"urlset"`
+	XMLNS   string           `xml:"xmlns,attr"`
+	URLs    []sitemapURLItem `xml:"url"`
+}
+
+// sitemapURLItem is the xml element for a url
+type sitemapURLItem struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+// Sitemap adds a route serving /sitemap.xml with the urls returned by generator.
+func (m *Mux) Sitemap(generator SitemapGenerator) Route {
+	return m.Get("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) error {
+		urls, err := generator(r)
+		if err != nil {
+			return err
+		}
+
+		set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+		for _, u := range urls {
+			item := sitemapURLItem{Loc: u.Loc, ChangeFreq: u.ChangeFreq}
+			if !u.LastMod.IsZero() {
+				item.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+			}
+			if u.Priority > 0 {
+				item.Priority = fmt.Sprintf("%.1f", u.Priority)
+			}
+			set.URLs = append(set.URLs, item)
+		}
+
+		data, err := xml.Marshal(set)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		_, err = w.Write(append([]byte(xml.Header), data...))
+		return err
+	})
+}
+
+// WellKnown adds a route for /.well-known/name (RFC 8615) with the handler given.
+func (m *Mux) WellKnown(name string, handler HandlerFunc) Route {
+	return m.Get("/.well-known/"+strings.TrimPrefix(name, "/"), handler)
+}
+
+// SecurityPolicy describes the contents of security.txt (RFC 9116)
+type SecurityPolicy struct {
+	Contact            []string  // required, mailto: or https: urls
+	Expires            time.Time // required, defaults to one year from now
+	Encryption         string
+	Acknowledgments    string
+	PreferredLanguages string
+	Canonical          string
+	Policy             string
+	Hiring             string
+}
+
+// String returns the policy formatted for security.txt
+func (p SecurityPolicy) String() string {
+	var b strings.Builder
+	for _, c := range p.Contact {
+		fmt.Fprintf(&b, "Contact: %s\n", c)
+	}
+	expires := p.Expires
+	if expires.IsZero() {
+		expires = time.Now().AddDate(1, 0, 0)
+	}
+	fmt.Fprintf(&b, "Expires: %s\n", expires.UTC().Format(time.RFC3339))
+	fields := []struct{ name, value string }{
+		{"Encryption", p.Encryption},
+		{"Acknowledgments", p.Acknowledgments},
+		{"Preferred-Languages", p.PreferredLanguages},
+		{"Canonical", p.Canonical},
+		{"Policy", p.Policy},
+		{"Hiring", p.Hiring},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", f.name, f.value)
+		}
+	}
+	return b.String()
+}
+
+// SecurityTxt adds a route serving /.well-known/security.txt with the policy given.
+// If Expires is not set the file is built per request, so that it never expires.
+func (m *Mux) SecurityTxt(policy SecurityPolicy) Route {
+	body := policy.String()
+	return m.WellKnown("security.txt", func(w http.ResponseWriter, r *http.Request) error {
+		b := body
+		if policy.Expires.IsZero() {
+			b = policy.String()
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err := w.Write([]byte(b))
+		return err
+	})
+}
+
+// ChangePassword adds a route redirecting /.well-known/change-password
+// to the change password page at url, so that password managers can find it.
+func (m *Mux) ChangePassword(url string) Route {
+	return m.WellKnown("change-password", func(w http.ResponseWriter, r *http.Request) error {
+		http.Redirect(w, r, url, http.StatusFound)
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRobots tests serving robots.txt.
+func TestRobots(t *testing.T) {
+	m := New()
+	m.Robots(RobotsPolicy{
+		Rules:    []RobotsRule{{Disallow: []string{"/admin"}}, {UserAgent: "BadBot", Disallow: []string{"/"}}},
+		Sitemaps: []string{"https://example.com/sitemap.xml"},
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	want := "User-agent: *\nDisallow: /admin\n\nUser-agent: BadBot\nDisallow: /\n\nSitemap: https://example.com/sitemap.xml\n"
+	if w.Body.String() != want || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("wellknown: wrong robots.txt got:%q", w.Body.String())
+	}
+}
+
+// TestSitemap tests serving sitemap.xml.
+func TestSitemap(t *testing.T) {
+	m := New()
+	m.Sitemap(func(r *http.Request) ([]SitemapURL, error) {
+		return []SitemapURL{
+			{Loc: "https://example.com/", Priority: 1},
+			{Loc: "https://example.com/about", LastMod: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		}, nil
+	})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, "<loc>https://example.com/about</loc><lastmod>2020-01-02T00:00:00Z</lastmod>") || !strings.Contains(body, "<priority>1.0</priority>") {
+		t.Errorf("wellknown: wrong sitemap got:%s", body)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") {
+		t.Errorf("wellknown: wrong sitemap content type got:%s", w.Header().Get("Content-Type"))
+	}
+}
+
+// TestWellKnown tests well-known routes.
+func TestWellKnown(t *testing.T) {
+	m := New()
+	m.SecurityTxt(SecurityPolicy{Contact: []string{"mailto:security@example.com"}, Expires: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)})
+	m.ChangePassword("/users/password")
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/security.txt", nil))
+	if w.Body.String() != "Contact: mailto:security@example.com\nExpires: 2030-01-01T00:00:00Z\n" {
+		t.Errorf("wellknown: wrong security.txt got:%q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/change-password", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/users/password" {
+		t.Errorf("wellknown: wrong change-password redirect got:%d %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+// TestSecurityTxtExpires tests the default expiry is computed per request.
+func TestSecurityTxtExpires(t *testing.T) {
+	m := New()
+	m.SecurityTxt(SecurityPolicy{Contact: []string{"mailto:security@example.com"}})
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/security.txt", nil))
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		value, ok := strings.CutPrefix(line, "Expires: ")
+		if !ok {
+			continue
+		}
+		expires, err := time.Parse(time.RFC3339, value)
+		if err != nil || expires.Before(time.Now().AddDate(1, 0, -1)) {
+			t.Errorf("wellknown: wrong security.txt expiry got:%s", value)
+		}
+		return
+	}
+	t.Errorf("wellknown: no security.txt expiry got:%q", w.Body.String())
+}